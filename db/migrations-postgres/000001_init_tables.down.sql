@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS job_execution_attempts;
+DROP TABLE IF EXISTS job_executions;
+DROP TABLE IF EXISTS schedule_jobs;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE schedule_jobs ADD COLUMN SecretRef varchar(128) NOT NULL DEFAULT ''; -- name of the secret resolved as jwt on each fire
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE schedule_jobs ADD COLUMN TlsProfile varchar(64) NOT NULL DEFAULT ''; -- name of tls profile of requests
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS host_limits (
+  Host varchar(255) NOT NULL PRIMARY KEY, -- target host, with port if any
+  RequestsPerSecond double precision NOT NULL DEFAULT 0, -- requests per second, 0 if unlimited
+  Burst integer NOT NULL DEFAULT 1, -- requests allowed at once beyond the rate
+  MaxInFlight integer NOT NULL DEFAULT 0, -- max requests in flight, 0 if unlimited
+  UpdateTime bigint NOT NULL -- update time epoch
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE schedule_jobs DROP COLUMN JitterSeconds;
+ALTER TABLE schedule_jobs DROP COLUMN JitterMode;
\ No newline at end of file
@@ -0,0 +1,69 @@
+CREATE TABLE IF NOT EXISTS `schedule_jobs` (
+  `JobID` varchar(36) NOT NULL PRIMARY KEY, -- job uuid
+  `JobKey` bigint NOT NULL, -- job key from quartz
+  `Status` tinyint NOT NULL DEFAULT 0, -- 1:enable,2:disable,3:done
+  `Name` varchar(32) NOT NULL, -- job name
+  `TriggerType` varchar(8) NOT NULL, -- job trigger type
+  `Expression` varchar(64) NOT NULL, -- trigger expression
+  `TimeZone` varchar(64) NOT NULL DEFAULT 'UTC', -- IANA time zone name of cron trigger
+  `HttpMethod` varchar(8) NOT NULL, -- http method
+  `HttpTargetUrl` varchar(320) NOT NULL, -- http target url
+  `HttpRequestBody` text NOT NULL, -- http request body
+  `HttpHeaders` text NOT NULL, -- http headers in json
+  `ContentType` varchar(128) NOT NULL DEFAULT 'application/octet-stream', -- http content type
+  `JsonWebToken` text NOT NULL, -- jwt
+  `TimeoutSeconds` int NOT NULL DEFAULT 30, -- request timeout in second per attempt
+  `RetryMaxAttempts` int NOT NULL DEFAULT 1, -- max attempts per execution
+  `RetryInitialBackoff` bigint NOT NULL DEFAULT 1000, -- initial backoff in millisecond
+  `RetryMultiplier` double NOT NULL DEFAULT 2, -- backoff multiplier
+  `RetryMaxBackoff` bigint NOT NULL DEFAULT 60000, -- max backoff in millisecond
+  `RetryStatusCodes` varchar(128) NOT NULL DEFAULT '408,429,500,502,503,504', -- comma separated retryable http status codes
+  `MisfireAction` varchar(16) NOT NULL DEFAULT 'skip', -- skip, fireOnce or fireAll
+  `MisfireMaxRuns` int NOT NULL DEFAULT 10, -- max missed fires caught up by fireAll
+  `StartAt` bigint NOT NULL DEFAULT 0, -- epoch in second the job fires from, 0 if unbounded
+  `EndAt` bigint NOT NULL DEFAULT 0, -- epoch in second the job fires until, 0 if unbounded
+  `MaxRuns` int NOT NULL DEFAULT 0, -- max number of runs, 0 if unlimited
+  `PausedTime` bigint NOT NULL DEFAULT 0, -- epoch in second the job was paused at, 0 while not paused
+  `Version` int NOT NULL DEFAULT 1, -- row version for optimistic concurrency
+  `LastFireTime` bigint NOT NULL DEFAULT 0, -- scheduled time epoch in millisecond of the latest fire delivered
+  `RunCount` int NOT NULL DEFAULT 0, -- number of runs fired
+  `CreationTime` bigint NOT NULL, -- creation time epoch
+  `UpdateTime` bigint NOT NULL -- update time epoch
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `JOB_KEY` ON `schedule_jobs` (`JobID`,`JobKey`);
+CREATE INDEX IF NOT EXISTS `CREATION_TIME` ON `schedule_jobs` (`CreationTime`);
+CREATE INDEX IF NOT EXISTS `UPDATE_TIME` ON `schedule_jobs` (`UpdateTime`);
+CREATE INDEX IF NOT EXISTS `STATUS` ON `schedule_jobs` (`Status`);
+
+CREATE TABLE IF NOT EXISTS `job_executions` (
+  `ExecutionID` varchar(36) NOT NULL PRIMARY KEY, -- execution uuid
+  `JobID` varchar(36) NOT NULL, -- job uuid
+  `Status` tinyint NOT NULL DEFAULT 0, -- 1:succeeded,2:failed,3:cancelled,4:running
+  `ScheduledTime` bigint NOT NULL, -- scheduled fire time epoch in millisecond
+  `StartTime` bigint NOT NULL, -- start time epoch in millisecond
+  `EndTime` bigint NOT NULL, -- end time epoch in millisecond
+  `HttpStatusCode` int NOT NULL DEFAULT 0, -- http response status code
+  `Latency` bigint NOT NULL DEFAULT 0, -- latency in millisecond
+  `Attempts` int NOT NULL DEFAULT 1, -- number of attempts
+  `ResponseBody` text NOT NULL, -- truncated http response body
+  `ErrorMessage` text NOT NULL, -- error message
+  `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' -- schedule, manual, catchup or misfire
+);
+
+CREATE INDEX IF NOT EXISTS `JOB_ID_START_TIME` ON `job_executions` (`JobID`,`StartTime`);
+CREATE INDEX IF NOT EXISTS `EXECUTION_STATUS` ON `job_executions` (`Status`);
+
+CREATE TABLE IF NOT EXISTS `job_execution_attempts` (
+  `ExecutionID` varchar(36) NOT NULL, -- execution uuid
+  `Attempt` int NOT NULL, -- attempt number starting from 1
+  `JobID` varchar(36) NOT NULL, -- job uuid
+  `StartTime` bigint NOT NULL, -- start time epoch in millisecond
+  `EndTime` bigint NOT NULL, -- end time epoch in millisecond
+  `HttpStatusCode` int NOT NULL DEFAULT 0, -- http response status code
+  `Latency` bigint NOT NULL DEFAULT 0, -- latency in millisecond
+  `ErrorMessage` text NOT NULL, -- error message
+  PRIMARY KEY (`ExecutionID`,`Attempt`)
+);
+
+CREATE INDEX IF NOT EXISTS `JOB_ID` ON `job_execution_attempts` (`JobID`);
\ No newline at end of file
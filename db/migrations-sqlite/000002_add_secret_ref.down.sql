@@ -0,0 +1 @@
+ALTER TABLE `schedule_jobs` DROP COLUMN `SecretRef`;
\ No newline at end of file
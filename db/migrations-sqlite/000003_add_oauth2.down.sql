@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs` DROP COLUMN `AuthMode`;
+ALTER TABLE `schedule_jobs` DROP COLUMN `OAuth2TokenUrl`;
+ALTER TABLE `schedule_jobs` DROP COLUMN `OAuth2ClientID`;
+ALTER TABLE `schedule_jobs` DROP COLUMN `OAuth2ClientSecret`;
+ALTER TABLE `schedule_jobs` DROP COLUMN `OAuth2Scopes`;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs` ADD COLUMN `AuthMode` varchar(16) NOT NULL DEFAULT 'bearer'; -- bearer:jwt or secret ref,oauth2:client credentials
+ALTER TABLE `schedule_jobs` ADD COLUMN `OAuth2TokenUrl` varchar(320) NOT NULL DEFAULT ''; -- oauth2 token endpoint
+ALTER TABLE `schedule_jobs` ADD COLUMN `OAuth2ClientID` varchar(256) NOT NULL DEFAULT ''; -- oauth2 client id
+ALTER TABLE `schedule_jobs` ADD COLUMN `OAuth2ClientSecret` text NOT NULL DEFAULT ''; -- oauth2 client secret
+ALTER TABLE `schedule_jobs` ADD COLUMN `OAuth2Scopes` varchar(512) NOT NULL DEFAULT ''; -- space separated oauth2 scopes
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `schedule_jobs` DROP COLUMN `SigningSecrets`;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE `schedule_jobs` ADD COLUMN `SigningSecrets` text NOT NULL DEFAULT ''; -- secrets signing requests in json
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `host_limits`;
\ No newline at end of file
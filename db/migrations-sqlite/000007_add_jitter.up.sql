@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs` ADD COLUMN `JitterSeconds` integer NOT NULL DEFAULT 0; -- window of delays of fires in seconds
+ALTER TABLE `schedule_jobs` ADD COLUMN `JitterMode` varchar(8) NOT NULL DEFAULT 'random'; -- mode of delays of fires: random or spread
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `job_executions` (
+  `ExecutionID` varchar(36) NOT NULL COMMENT 'execution uuid',
+  `JobID` varchar(36) NOT NULL COMMENT 'job uuid',
+  `Status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '1:succeeded,2:failed',
+  `ScheduledTime` bigint(20) NOT NULL COMMENT 'scheduled fire time epoch in millisecond',
+  `StartTime` bigint(20) NOT NULL COMMENT 'start time epoch in millisecond',
+  `EndTime` bigint(20) NOT NULL COMMENT 'end time epoch in millisecond',
+  `HttpStatusCode` int(11) NOT NULL DEFAULT 0 COMMENT 'http response status code',
+  `Latency` bigint(20) NOT NULL DEFAULT 0 COMMENT 'latency in millisecond',
+  `ResponseBody` text NOT NULL COMMENT 'truncated http response body',
+  `ErrorMessage` text NOT NULL COMMENT 'error message'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='job executions table';
+
+ALTER TABLE `job_executions`
+  ADD PRIMARY KEY (`ExecutionID`),
+  ADD KEY `JOB_ID_START_TIME` (`JobID`,`StartTime`),
+  ADD KEY `STATUS` (`Status`);
\ No newline at end of file
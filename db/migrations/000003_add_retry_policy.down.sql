@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS `job_execution_attempts`;
+
+ALTER TABLE `job_executions`
+  DROP COLUMN `Attempts`;
+
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `RetryMaxAttempts`,
+  DROP COLUMN `RetryInitialBackoff`,
+  DROP COLUMN `RetryMultiplier`,
+  DROP COLUMN `RetryMaxBackoff`,
+  DROP COLUMN `RetryStatusCodes`;
\ No newline at end of file
@@ -0,0 +1,24 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `RetryMaxAttempts` int(11) NOT NULL DEFAULT 1 COMMENT 'max attempts per execution' AFTER `JsonWebToken`,
+  ADD COLUMN `RetryInitialBackoff` bigint(20) NOT NULL DEFAULT 1000 COMMENT 'initial backoff in millisecond' AFTER `RetryMaxAttempts`,
+  ADD COLUMN `RetryMultiplier` double NOT NULL DEFAULT 2 COMMENT 'backoff multiplier' AFTER `RetryInitialBackoff`,
+  ADD COLUMN `RetryMaxBackoff` bigint(20) NOT NULL DEFAULT 60000 COMMENT 'max backoff in millisecond' AFTER `RetryMultiplier`,
+  ADD COLUMN `RetryStatusCodes` varchar(128) NOT NULL DEFAULT '408,429,500,502,503,504' COMMENT 'comma separated retryable http status codes' AFTER `RetryMaxBackoff`;
+
+ALTER TABLE `job_executions`
+  ADD COLUMN `Attempts` int(11) NOT NULL DEFAULT 1 COMMENT 'number of attempts' AFTER `Latency`;
+
+CREATE TABLE IF NOT EXISTS `job_execution_attempts` (
+  `ExecutionID` varchar(36) NOT NULL COMMENT 'execution uuid',
+  `Attempt` int(11) NOT NULL COMMENT 'attempt number starting from 1',
+  `JobID` varchar(36) NOT NULL COMMENT 'job uuid',
+  `StartTime` bigint(20) NOT NULL COMMENT 'start time epoch in millisecond',
+  `EndTime` bigint(20) NOT NULL COMMENT 'end time epoch in millisecond',
+  `HttpStatusCode` int(11) NOT NULL DEFAULT 0 COMMENT 'http response status code',
+  `Latency` bigint(20) NOT NULL DEFAULT 0 COMMENT 'latency in millisecond',
+  `ErrorMessage` text NOT NULL COMMENT 'error message'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='job execution attempts table';
+
+ALTER TABLE `job_execution_attempts`
+  ADD PRIMARY KEY (`ExecutionID`,`Attempt`),
+  ADD KEY `JOB_ID` (`JobID`);
\ No newline at end of file
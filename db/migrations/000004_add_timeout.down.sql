@@ -0,0 +1,5 @@
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `Status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '1:succeeded,2:failed';
+
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `TimeoutSeconds`;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `TimeoutSeconds` int(11) NOT NULL DEFAULT 30 COMMENT 'request timeout in second per attempt' AFTER `JsonWebToken`;
+
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `Status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '1:succeeded,2:failed,3:cancelled';
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `HttpHeaders`,
+  DROP COLUMN `ContentType`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `HttpHeaders` text NOT NULL COMMENT 'http headers in json' AFTER `HttpRequestBody`,
+  ADD COLUMN `ContentType` varchar(128) NOT NULL DEFAULT 'application/octet-stream' COMMENT 'http content type' AFTER `HttpHeaders`;
\ No newline at end of file
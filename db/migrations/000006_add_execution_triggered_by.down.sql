@@ -0,0 +1,3 @@
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `Status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '1:succeeded,2:failed,3:cancelled',
+  DROP COLUMN `TriggeredBy`;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `Status` tinyint(4) NOT NULL DEFAULT 0 COMMENT '1:succeeded,2:failed,3:cancelled,4:running',
+  ADD COLUMN `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' COMMENT 'schedule or manual' AFTER `ErrorMessage`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `TimeZone` varchar(64) NOT NULL DEFAULT 'UTC' COMMENT 'IANA time zone name of cron trigger' AFTER `Expression`;
\ No newline at end of file
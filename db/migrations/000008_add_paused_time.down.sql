@@ -0,0 +1,5 @@
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' COMMENT 'schedule or manual';
+
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `PausedTime`;
\ No newline at end of file
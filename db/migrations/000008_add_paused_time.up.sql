@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `PausedTime` bigint(20) NOT NULL DEFAULT 0 COMMENT 'epoch in second the job was paused at, 0 while not paused' AFTER `RetryStatusCodes`;
+
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' COMMENT 'schedule, manual or catchup';
\ No newline at end of file
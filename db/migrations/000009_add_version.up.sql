@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `Version` int(11) NOT NULL DEFAULT 1 COMMENT 'row version for optimistic concurrency' AFTER `PausedTime`;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `scheduler_leases`;
\ No newline at end of file
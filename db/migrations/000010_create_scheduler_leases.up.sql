@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `scheduler_leases` (
+  `Name` varchar(64) NOT NULL COMMENT 'lease name',
+  `Holder` varchar(255) NOT NULL COMMENT 'node holding the lease',
+  `ExpireTime` bigint(20) NOT NULL DEFAULT 0 COMMENT 'lease expiry epoch in millisecond'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='leader election leases table';
+
+ALTER TABLE `scheduler_leases`
+  ADD PRIMARY KEY (`Name`);
\ No newline at end of file
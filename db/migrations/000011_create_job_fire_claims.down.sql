@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `job_fire_claims`;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `job_fire_claims` (
+  `JobID` varchar(36) NOT NULL COMMENT 'job uuid',
+  `FireTime` bigint(20) NOT NULL COMMENT 'scheduled fire time epoch in millisecond',
+  `Holder` varchar(255) NOT NULL COMMENT 'node claiming the fire',
+  `ClaimTime` bigint(20) NOT NULL COMMENT 'claim time epoch in millisecond'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='job fire claims table';
+
+ALTER TABLE `job_fire_claims`
+  ADD PRIMARY KEY (`JobID`,`FireTime`),
+  ADD KEY `CLAIM_TIME` (`ClaimTime`);
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' COMMENT 'schedule, manual or catchup';
+
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `MisfireAction`,
+  DROP COLUMN `MisfireMaxRuns`,
+  DROP COLUMN `LastFireTime`;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `MisfireAction` varchar(16) NOT NULL DEFAULT 'skip' COMMENT 'skip, fireOnce or fireAll' AFTER `RetryStatusCodes`,
+  ADD COLUMN `MisfireMaxRuns` int(11) NOT NULL DEFAULT 10 COMMENT 'max missed fires caught up by fireAll' AFTER `MisfireAction`,
+  ADD COLUMN `LastFireTime` bigint(20) NOT NULL DEFAULT 0 COMMENT 'scheduled time epoch in millisecond of the latest fire delivered' AFTER `Version`;
+
+ALTER TABLE `job_executions`
+  MODIFY COLUMN `TriggeredBy` varchar(16) NOT NULL DEFAULT 'schedule' COMMENT 'schedule, manual, catchup or misfire';
\ No newline at end of file
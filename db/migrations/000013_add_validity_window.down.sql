@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `StartAt`,
+  DROP COLUMN `EndAt`,
+  DROP COLUMN `MaxRuns`,
+  DROP COLUMN `RunCount`;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `StartAt` bigint(20) NOT NULL DEFAULT 0 COMMENT 'epoch in second the job fires from, 0 if unbounded' AFTER `MisfireMaxRuns`,
+  ADD COLUMN `EndAt` bigint(20) NOT NULL DEFAULT 0 COMMENT 'epoch in second the job fires until, 0 if unbounded' AFTER `StartAt`,
+  ADD COLUMN `MaxRuns` int(11) NOT NULL DEFAULT 0 COMMENT 'max number of runs, 0 if unlimited' AFTER `EndAt`,
+  ADD COLUMN `RunCount` int(11) NOT NULL DEFAULT 0 COMMENT 'number of runs fired' AFTER `LastFireTime`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `SecretRef`;
\ No newline at end of file
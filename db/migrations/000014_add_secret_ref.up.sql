@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `SecretRef` varchar(128) NOT NULL DEFAULT '' COMMENT 'name of the secret resolved as jwt on each fire' AFTER `JsonWebToken`;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `AuthMode`,
+  DROP COLUMN `OAuth2TokenUrl`,
+  DROP COLUMN `OAuth2ClientID`,
+  DROP COLUMN `OAuth2ClientSecret`,
+  DROP COLUMN `OAuth2Scopes`;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `AuthMode` varchar(16) NOT NULL DEFAULT 'bearer' COMMENT 'bearer:jwt or secret ref,oauth2:client credentials' AFTER `SecretRef`,
+  ADD COLUMN `OAuth2TokenUrl` varchar(320) NOT NULL DEFAULT '' COMMENT 'oauth2 token endpoint' AFTER `AuthMode`,
+  ADD COLUMN `OAuth2ClientID` varchar(256) NOT NULL DEFAULT '' COMMENT 'oauth2 client id' AFTER `OAuth2TokenUrl`,
+  ADD COLUMN `OAuth2ClientSecret` text NOT NULL COMMENT 'oauth2 client secret' AFTER `OAuth2ClientID`,
+  ADD COLUMN `OAuth2Scopes` varchar(512) NOT NULL DEFAULT '' COMMENT 'space separated oauth2 scopes' AFTER `OAuth2ClientSecret`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `SigningSecrets`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `SigningSecrets` text NOT NULL COMMENT 'secrets signing requests in json' AFTER `OAuth2Scopes`;
\ No newline at end of file
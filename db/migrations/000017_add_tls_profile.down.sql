@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `TlsProfile`;
\ No newline at end of file
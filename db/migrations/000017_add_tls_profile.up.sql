@@ -0,0 +1,2 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `TlsProfile` varchar(64) NOT NULL DEFAULT '' COMMENT 'name of tls profile of requests' AFTER `SigningSecrets`;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS `host_limits` (
+  `Host` varchar(255) NOT NULL COMMENT 'target host, with port if any',
+  `RequestsPerSecond` double NOT NULL DEFAULT 0 COMMENT 'requests per second, 0 if unlimited',
+  `Burst` int(11) NOT NULL DEFAULT 1 COMMENT 'requests allowed at once beyond the rate',
+  `MaxInFlight` int(11) NOT NULL DEFAULT 0 COMMENT 'max requests in flight, 0 if unlimited',
+  `UpdateTime` bigint(20) NOT NULL COMMENT 'update time epoch'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci COMMENT='per target host limits table';
+
+ALTER TABLE `host_limits`
+  ADD PRIMARY KEY (`Host`);
\ No newline at end of file
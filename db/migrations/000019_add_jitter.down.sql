@@ -0,0 +1,3 @@
+ALTER TABLE `schedule_jobs`
+  DROP COLUMN `JitterSeconds`,
+  DROP COLUMN `JitterMode`;
\ No newline at end of file
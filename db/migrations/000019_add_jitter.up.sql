@@ -0,0 +1,3 @@
+ALTER TABLE `schedule_jobs`
+  ADD COLUMN `JitterSeconds` int(11) NOT NULL DEFAULT 0 COMMENT 'window of delays of fires in seconds' AFTER `TlsProfile`,
+  ADD COLUMN `JitterMode` varchar(8) NOT NULL DEFAULT 'random' COMMENT 'mode of delays of fires: random or spread' AFTER `JitterSeconds`;
\ No newline at end of file
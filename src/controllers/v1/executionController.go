@@ -0,0 +1,287 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/cloud01-wu/cgsl/datetime"
+	"github.com/cloud01-wu/cgsl/httpx/model"
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/cgsl/utils"
+	"github.com/cloud01-wu/scheduler/helper"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type GetJobExecutionResult struct {
+	ExecutionID    string `json:"executionId"`
+	JobID          string `json:"jobId"`
+	Status         int    `json:"status"`
+	ScheduledTime  string `json:"scheduledTime"`
+	StartTime      string `json:"startTime"`
+	EndTime        string `json:"endTime"`
+	HttpStatusCode int    `json:"httpStatusCode"`
+	Latency        int64  `json:"latency"`
+	Attempts       int    `json:"attempts"`
+	ResponseBody   string `json:"responseBody"`
+	ErrorMessage   string `json:"errorMessage"`
+	TriggeredBy    string `json:"triggeredBy"`
+}
+
+// formatEpochInMilli formats epoch in millisecond, leaving unset times empty
+func formatEpochInMilli(epoch int64) string {
+	if epoch == 0 {
+		return ""
+	}
+
+	return datetime.FromTime(time.UnixMilli(epoch)).StringWithFormat(datetime.TimeFormatMilli)
+}
+
+func newGetJobExecutionResult(execution *orm.JobExecution) *GetJobExecutionResult {
+	return &GetJobExecutionResult{
+		ExecutionID:    execution.ExecutionID,
+		JobID:          execution.JobID,
+		Status:         execution.Status,
+		ScheduledTime:  formatEpochInMilli(execution.ScheduledTime),
+		StartTime:      formatEpochInMilli(execution.StartTime),
+		EndTime:        formatEpochInMilli(execution.EndTime),
+		HttpStatusCode: execution.HttpStatusCode,
+		Latency:        execution.Latency,
+		Attempts:       execution.Attempts,
+		ResponseBody:   execution.ResponseBody,
+		ErrorMessage:   execution.ErrorMessage,
+		TriggeredBy:    execution.TriggeredBy,
+	}
+}
+
+type GetJobExecutionAttemptResult struct {
+	ExecutionID    string `json:"executionId"`
+	Attempt        int    `json:"attempt"`
+	JobID          string `json:"jobId"`
+	StartTime      string `json:"startTime"`
+	EndTime        string `json:"endTime"`
+	HttpStatusCode int    `json:"httpStatusCode"`
+	Latency        int64  `json:"latency"`
+	ErrorMessage   string `json:"errorMessage"`
+}
+
+func newGetJobExecutionAttemptResult(executionAttempt *orm.JobExecutionAttempt) *GetJobExecutionAttemptResult {
+	return &GetJobExecutionAttemptResult{
+		ExecutionID:    executionAttempt.ExecutionID,
+		Attempt:        executionAttempt.Attempt,
+		JobID:          executionAttempt.JobID,
+		StartTime:      formatEpochInMilli(executionAttempt.StartTime),
+		EndTime:        formatEpochInMilli(executionAttempt.EndTime),
+		HttpStatusCode: executionAttempt.HttpStatusCode,
+		Latency:        executionAttempt.Latency,
+		ErrorMessage:   executionAttempt.ErrorMessage,
+	}
+}
+
+func GetJobExecutions(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	query := r.URL.Query()
+	from := GetIntFromQuery(query, "from", 0)
+	size := GetIntFromQuery(query, "size", 0)
+	params["JobID"] = jobID
+	params["From"] = from
+	params["Size"] = size
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	jobExecutions, totalCount, err := store.New().ListExecutions(jobID, from, size)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	entities := []*GetJobExecutionResult{}
+	for i := range jobExecutions {
+		entities = append(entities, newGetJobExecutionResult(&jobExecutions[i]))
+	}
+
+	resultObject.Meta = &model.Meta{
+		From:  from,
+		Size:  len(entities),
+		Total: totalCount,
+	}
+	resultObject.Data = entities
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func RunJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	query := r.URL.Query()
+	async := GetBoolFromQuery(query, "async", false)
+	params["JobID"] = jobID
+	params["Async"] = async
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	scheduleJob, err := store.New().Get(jobID)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	// run the job outside of its trigger
+	execution := helper.RunJob(*scheduleJob, async)
+	params["ExecutionID"] = execution.ExecutionID
+
+	resultObject.Data = newGetJobExecutionResult(execution)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func GetJobExecution(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+	executionID := vars["executionID"]
+
+	params["JobID"] = jobID
+	params["ExecutionID"] = executionID
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	if !govalidator.IsUUIDv4(executionID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid execution UUID")),
+		))
+		return
+	}
+
+	execution, err := store.New().GetExecution(jobID, executionID)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	resultObject.Data = newGetJobExecutionResult(execution)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func GetJobExecutionAttempts(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+	executionID := vars["executionID"]
+
+	params["JobID"] = jobID
+	params["ExecutionID"] = executionID
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	if !govalidator.IsUUIDv4(executionID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid execution UUID")),
+		))
+		return
+	}
+
+	executionAttempts, err := store.New().ListExecutionAttempts(jobID, executionID)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	entities := []*GetJobExecutionAttemptResult{}
+	for i := range executionAttempts {
+		entities = append(entities, newGetJobExecutionAttemptResult(&executionAttempts[i]))
+	}
+
+	resultObject.Meta = &model.Meta{
+		From:  0,
+		Size:  len(entities),
+		Total: len(entities),
+	}
+	resultObject.Data = entities
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
@@ -0,0 +1,288 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/cloud01-wu/cgsl/datetime"
+	"github.com/cloud01-wu/cgsl/httpx/model"
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/cgsl/utils"
+	"github.com/cloud01-wu/scheduler/helper"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type PutHostLimitRequest struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond" valid:"range(0|100000)~requestsPerSecond must be between 0 and 100000,optional"`
+	Burst             int     `json:"burst" valid:"range(1|100000)~burst must be between 1 and 100000,optional"`
+	MaxInFlight       int     `json:"maxInFlight" valid:"range(0|100000)~maxInFlight must be between 0 and 100000,optional"`
+}
+
+type GetHostLimitResult struct {
+	Host              string  `json:"host"`
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+	MaxInFlight       int     `json:"maxInFlight"`
+	InFlight          int     `json:"inFlight"`
+	Queued            int     `json:"queued"`
+	UpdateTime        string  `json:"updateTime"`
+}
+
+func newGetHostLimitResult(hostLimit *orm.HostLimit) *GetHostLimitResult {
+	inFlight, queued := helper.HostLimitUsage(hostLimit.Host)
+
+	updateTime := ""
+	if hostLimit.UpdateTime != 0 {
+		updateTime = datetime.FromUnixTime(hostLimit.UpdateTime).String()
+	}
+
+	return &GetHostLimitResult{
+		Host:              hostLimit.Host,
+		RequestsPerSecond: hostLimit.RequestsPerSecond,
+		Burst:             hostLimit.Burst,
+		MaxInFlight:       hostLimit.MaxInFlight,
+		InFlight:          inFlight,
+		Queued:            queued,
+		UpdateTime:        updateTime,
+	}
+}
+
+// validTargetHost tells whether host is a host name or IP address, with a port
+// if any, or the default host
+func validTargetHost(host string) bool {
+	if host == helper.DefaultHostLimitHost {
+		return true
+	}
+
+	hostname := host
+	if splitHostname, port, err := net.SplitHostPort(host); err == nil {
+		portNumber, err := strconv.Atoi(port)
+		if err != nil || portNumber < 1 || portNumber > 65535 {
+			return false
+		}
+		hostname = splitHostname
+	}
+	hostname = strings.TrimSuffix(strings.TrimPrefix(hostname, "["), "]")
+
+	return govalidator.IsIP(hostname) || govalidator.IsDNSName(hostname)
+}
+
+func GetHostLimits(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	hostLimits, err := store.New().ListHostLimits()
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	entities := []*GetHostLimitResult{}
+	for _, hostLimit := range hostLimits {
+		entities = append(entities, newGetHostLimitResult(&hostLimit))
+	}
+
+	resultObject.Meta = &model.Meta{
+		From:  0,
+		Size:  len(entities),
+		Total: len(entities),
+	}
+	resultObject.Data = entities
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func GetHostLimit(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	host := strings.ToLower(vars["host"])
+
+	params["Host"] = host
+
+	if !validTargetHost(host) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("invalid host")),
+		))
+		return
+	}
+
+	hostLimit, err := store.New().GetHostLimit(host)
+	if errors.Is(err, store.ErrHostLimitNotFound) {
+		// hosts without a limit of their own are limited by the default one
+		defaultLimit := helper.DefaultHostLimit()
+		hostLimit, err = &defaultLimit, nil
+		hostLimit.Host = host
+	}
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	resultObject.Data = newGetHostLimitResult(hostLimit)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func PutHostLimit(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	host := strings.ToLower(vars["host"])
+
+	// receive post data
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	// deserialize data
+	requestObject := model.Request{
+		Desire: &PutHostLimitRequest{},
+		Data:   nil,
+	}
+
+	err = json.Unmarshal(body, &requestObject)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	requestData, ok := requestObject.Desire.(*PutHostLimitRequest)
+	if !ok {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("unexpected request data")),
+		))
+		return
+	}
+
+	params["Host"] = host
+	params["HttpBody"] = requestData
+
+	if !validTargetHost(host) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("invalid host")),
+		))
+		return
+	}
+
+	_, err = govalidator.ValidateStruct(requestData)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	hostLimit := orm.HostLimit{
+		Host:              host,
+		RequestsPerSecond: requestData.RequestsPerSecond,
+		Burst:             requestData.Burst,
+		MaxInFlight:       requestData.MaxInFlight,
+		UpdateTime:        datetime.Now().EpochInSecond(),
+	}
+	if hostLimit.Burst == 0 {
+		hostLimit.Burst = 1
+	}
+
+	err = store.New().PutHostLimit(&hostLimit)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	// take effect at once, while other replicas catch up on their next refresh
+	helper.SetHostLimit(hostLimit)
+
+	resultObject.Data = newGetHostLimitResult(&hostLimit)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func DeleteHostLimit(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	host := strings.ToLower(vars["host"])
+
+	params["Host"] = host
+
+	if !validTargetHost(host) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("invalid host")),
+		))
+		return
+	}
+
+	err := store.New().DeleteHostLimit(host)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	helper.RemoveHostLimit(host)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
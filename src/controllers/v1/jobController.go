@@ -1,62 +1,520 @@
 package v1
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/asaskevich/govalidator"
-	"github.com/blockloop/scan"
 	"github.com/cloud01-wu/cgsl/datetime"
-	"github.com/cloud01-wu/cgsl/dbx"
 	"github.com/cloud01-wu/cgsl/httpx/model"
 	"github.com/cloud01-wu/cgsl/logger"
 	"github.com/cloud01-wu/cgsl/utils"
 	"github.com/cloud01-wu/scheduler/global"
 	"github.com/cloud01-wu/scheduler/helper"
 	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/secret"
+	"github.com/cloud01-wu/scheduler/store"
 	"github.com/gorilla/mux"
 	"github.com/reugn/go-quartz/quartz"
 	"go.uber.org/zap"
 )
 
+type RetryPolicy struct {
+	MaxAttempts          int     `json:"maxAttempts" valid:"range(1|10)~maxAttempts must be between 1 and 10"`
+	InitialBackoffMs     int64   `json:"initialBackoffMs" valid:"range(1|3600000),optional"`
+	Multiplier           float64 `json:"multiplier" valid:"range(1|10),optional"`
+	MaxBackoffMs         int64   `json:"maxBackoffMs" valid:"range(1|3600000),optional"`
+	RetryableStatusCodes []int   `json:"retryableStatusCodes" valid:"httpstatuscodes~retryableStatusCodes must be between 400 and 599,optional"`
+}
+
+type MisfirePolicy struct {
+	Action  string `json:"action" valid:"in(skip|fireOnce|fireAll)~action must be one of skip|fireOnce|fireAll"`
+	MaxRuns int    `json:"maxRuns" valid:"range(1|100)~maxRuns must be between 1 and 100,optional"`
+}
+
+type OAuth2 struct {
+	TokenUrl     string   `json:"tokenUrl" valid:"requrl~tokenUrl does not validate as valid HTTP request URL"`
+	ClientID     string   `json:"clientId" valid:"stringlength(1|256)~clientId must be between 1 and 256 characters"`
+	ClientSecret string   `json:"clientSecret" valid:"-"`
+	Scopes       []string `json:"scopes" valid:"oauth2scopes~scopes must be non-empty and free of spaces,optional"`
+}
+
 type PostJobRequest struct {
-	Name            string `json:"name" valid:"stringlength(1|32)"`
-	TriggerType     string `json:"triggerType" valid:"in(cron|interval|once)"`
-	Expression      string `json:"expression" valid:"expression~expression does not validate as specific cron expression. See https://github.com/reugn/go-quartz"`
-	HttpMethod      string `json:"httpMethod" valid:"in(POST|GET|PUT|DELETE)"`
-	HttpTargetUrl   string `json:"httpTargetUrl" valid:"requrl~httpTargetUrl does not validate as valid HTTP request URL"`
-	HttpRequestBody string `json:"httpRequestBody" valid:"-"`
-	JsonWebToken    string `json:"jsonWebToken" valid:"-"`
+	Name            string            `json:"name" valid:"stringlength(1|32)"`
+	TriggerType     string            `json:"triggerType" valid:"in(cron|interval|once|at)"`
+	Expression      string            `json:"expression" valid:"expression~expression does not validate as specific cron/interval/once/at expression. See https://github.com/reugn/go-quartz"`
+	TimeZone        string            `json:"timeZone" valid:"timezone~timeZone does not validate as IANA time zone name,optional"`
+	HttpMethod      string            `json:"httpMethod" valid:"in(POST|GET|PUT|DELETE)"`
+	HttpTargetUrl   string            `json:"httpTargetUrl" valid:"requrl~httpTargetUrl does not validate as valid HTTP request URL"`
+	HttpRequestBody string            `json:"httpRequestBody" valid:"-"`
+	HttpHeaders     map[string]string `json:"httpHeaders" valid:"httpheaders~httpHeaders must not contain invalid or reserved headers such as Host and Content-Length,optional"`
+	ContentType     string            `json:"contentType" valid:"contenttype~contentType does not validate as valid media type,optional"`
+	JsonWebToken    string            `json:"jsonWebToken" valid:"-"`
+	SecretRef       string            `json:"secretRef" valid:"secretref~secretRef must be up to 128 letters or digits or . _ - starting with a letter or digit,optional"`
+	AuthMode        string            `json:"authMode" valid:"in(bearer|oauth2)~authMode must be bearer or oauth2,optional"`
+	OAuth2          *OAuth2           `json:"oauth2" valid:"optional"`
+	SigningSecrets  []string          `json:"signingSecrets" valid:"signingsecrets~signingSecrets must be up to 5 secrets of at least 16 characters,optional"`
+	TlsProfile      string            `json:"tlsProfile" valid:"tlsprofile~tlsProfile does not name a loaded TLS profile,optional"`
+	JitterSeconds   int               `json:"jitterSeconds" valid:"range(1|3600)~jitterSeconds must be between 1 and 3600,optional"`
+	JitterMode      string            `json:"jitterMode" valid:"in(random|spread)~jitterMode must be random or spread,optional"`
+	TimeoutSeconds  int               `json:"timeoutSeconds" valid:"range(1|3600)~timeoutSeconds must be between 1 and 3600,optional"`
+	RetryPolicy     *RetryPolicy      `json:"retryPolicy" valid:"optional"`
+	MisfirePolicy   *MisfirePolicy    `json:"misfirePolicy" valid:"optional"`
+	StartAt         string            `json:"startAt" valid:"rfc3339~startAt does not validate as RFC 3339 timestamp,optional"`
+	EndAt           string            `json:"endAt" valid:"rfc3339~endAt does not validate as RFC 3339 timestamp,optional"`
+	MaxRuns         int               `json:"maxRuns" valid:"range(1|1000000)~maxRuns must be between 1 and 1000000,optional"`
 }
 
 type PutJobRequest struct {
-	Status          int    `json:"status" valid:"range(1|2)~status must be 1 (enable) or 2 (disable)"`
-	Name            string `json:"name" valid:"stringlength(1|32)"`
-	TriggerType     string `json:"triggerType" valid:"in(cron|interval|once)"`
-	Expression      string `json:"expression" valid:"expression~expression does not validate as specific cron/interval/once expression. See https://github.com/reugn/go-quartz"`
-	HttpMethod      string `json:"httpMethod" valid:"in(POST|GET|PUT|DELETE)"`
-	HttpTargetUrl   string `json:"httpTargetUrl" valid:"requrl~httpTargetUrl does not validate as valid HTTP request URL"`
-	HttpRequestBody string `json:"httpRequestBody" valid:"-"`
-	JsonWebToken    string `json:"jsonWebToken" valid:"-"`
+	Status          int               `json:"status" valid:"range(1|2)~status must be 1 (enable) or 2 (disable)"`
+	Name            string            `json:"name" valid:"stringlength(1|32)"`
+	TriggerType     string            `json:"triggerType" valid:"in(cron|interval|once|at)"`
+	Expression      string            `json:"expression" valid:"expression~expression does not validate as specific cron/interval/once/at expression. See https://github.com/reugn/go-quartz"`
+	TimeZone        string            `json:"timeZone" valid:"timezone~timeZone does not validate as IANA time zone name,optional"`
+	HttpMethod      string            `json:"httpMethod" valid:"in(POST|GET|PUT|DELETE)"`
+	HttpTargetUrl   string            `json:"httpTargetUrl" valid:"requrl~httpTargetUrl does not validate as valid HTTP request URL"`
+	HttpRequestBody string            `json:"httpRequestBody" valid:"-"`
+	HttpHeaders     map[string]string `json:"httpHeaders" valid:"httpheaders~httpHeaders must not contain invalid or reserved headers such as Host and Content-Length,optional"`
+	ContentType     string            `json:"contentType" valid:"contenttype~contentType does not validate as valid media type,optional"`
+	JsonWebToken    string            `json:"jsonWebToken" valid:"-"`
+	SecretRef       string            `json:"secretRef" valid:"secretref~secretRef must be up to 128 letters or digits or . _ - starting with a letter or digit,optional"`
+	AuthMode        string            `json:"authMode" valid:"in(bearer|oauth2)~authMode must be bearer or oauth2,optional"`
+	OAuth2          *OAuth2           `json:"oauth2" valid:"optional"`
+	SigningSecrets  []string          `json:"signingSecrets" valid:"signingsecrets~signingSecrets must be up to 5 secrets of at least 16 characters,optional"`
+	TlsProfile      string            `json:"tlsProfile" valid:"tlsprofile~tlsProfile does not name a loaded TLS profile,optional"`
+	JitterSeconds   int               `json:"jitterSeconds" valid:"range(1|3600)~jitterSeconds must be between 1 and 3600,optional"`
+	JitterMode      string            `json:"jitterMode" valid:"in(random|spread)~jitterMode must be random or spread,optional"`
+	TimeoutSeconds  int               `json:"timeoutSeconds" valid:"range(1|3600)~timeoutSeconds must be between 1 and 3600,optional"`
+	RetryPolicy     *RetryPolicy      `json:"retryPolicy" valid:"optional"`
+	MisfirePolicy   *MisfirePolicy    `json:"misfirePolicy" valid:"optional"`
+	StartAt         string            `json:"startAt" valid:"rfc3339~startAt does not validate as RFC 3339 timestamp,optional"`
+	EndAt           string            `json:"endAt" valid:"rfc3339~endAt does not validate as RFC 3339 timestamp,optional"`
+	MaxRuns         int               `json:"maxRuns" valid:"range(1|1000000)~maxRuns must be between 1 and 1000000,optional"`
 }
 
 type GetJobResult struct {
-	JobID           string `json:"jobId"`
-	JobKey          int    `json:"jobKey"`
-	Status          int    `json:"status"`
-	Name            string `json:"name"`
-	TriggerType     string `json:"triggerType"`
-	Expression      string `json:"expression"`
-	HttpMethod      string `json:"httpMethod"`
-	HttpTargetUrl   string `json:"httpTargetUrl"`
-	HttpRequestBody string `json:"httpRequestBody"`
-	JsonWebToken    string `json:"jsonWebToken"`
-	CreationTime    string `json:"creationTime"`
-	UpdateTime      string `json:"updateTime"`
+	JobID           string            `json:"jobId"`
+	JobKey          int               `json:"jobKey"`
+	Status          int               `json:"status"`
+	Name            string            `json:"name"`
+	TriggerType     string            `json:"triggerType"`
+	Expression      string            `json:"expression"`
+	TimeZone        string            `json:"timeZone"`
+	HttpMethod      string            `json:"httpMethod"`
+	HttpTargetUrl   string            `json:"httpTargetUrl"`
+	HttpRequestBody string            `json:"httpRequestBody"`
+	HttpHeaders     map[string]string `json:"httpHeaders"`
+	ContentType     string            `json:"contentType"`
+	JsonWebToken    string            `json:"jsonWebToken"`
+	SecretRef       string            `json:"secretRef"`
+	AuthMode        string            `json:"authMode"`
+	OAuth2          *OAuth2           `json:"oauth2"`
+	SigningSecrets  []string          `json:"signingSecrets"`
+	TlsProfile      string            `json:"tlsProfile"`
+	JitterSeconds   int               `json:"jitterSeconds"`
+	JitterMode      string            `json:"jitterMode"`
+	TimeoutSeconds  int               `json:"timeoutSeconds"`
+	RetryPolicy     *RetryPolicy      `json:"retryPolicy"`
+	MisfirePolicy   *MisfirePolicy    `json:"misfirePolicy"`
+	StartAt         string            `json:"startAt"`
+	EndAt           string            `json:"endAt"`
+	MaxRuns         int               `json:"maxRuns"`
+	PausedTime      string            `json:"pausedTime"`
+	Version         int               `json:"version"`
+	LastFireTime    string            `json:"lastFireTime"`
+	RunCount        int               `json:"runCount"`
+	CreationTime    string            `json:"creationTime"`
+	UpdateTime      string            `json:"updateTime"`
+}
+
+func newGetJobResult(scheduleJob *orm.ScheduleJob) *GetJobResult {
+	pausedTime := ""
+	if scheduleJob.PausedTime != 0 {
+		pausedTime = datetime.FromUnixTime(scheduleJob.PausedTime).String()
+	}
+
+	startAt := ""
+	if scheduleJob.StartAt != 0 {
+		startAt = datetime.FromUnixTime(scheduleJob.StartAt).String()
+	}
+
+	endAt := ""
+	if scheduleJob.EndAt != 0 {
+		endAt = datetime.FromUnixTime(scheduleJob.EndAt).String()
+	}
+
+	return &GetJobResult{
+		JobID:           scheduleJob.JobID,
+		JobKey:          scheduleJob.JobKey,
+		Status:          scheduleJob.Status,
+		Name:            scheduleJob.Name,
+		TriggerType:     scheduleJob.TriggerType,
+		Expression:      scheduleJob.Expression,
+		TimeZone:        scheduleJob.TimeZone,
+		HttpMethod:      scheduleJob.HttpMethod,
+		HttpTargetUrl:   scheduleJob.HttpTargetUrl,
+		HttpRequestBody: scheduleJob.HttpRequestBody,
+		HttpHeaders:     redactHttpHeaders(helper.DecodeHttpHeaders(scheduleJob.HttpHeaders), secret.Redact),
+		ContentType:     scheduleJob.ContentType,
+		JsonWebToken:    secret.Redact(scheduleJob.JsonWebToken),
+		SecretRef:       scheduleJob.SecretRef,
+		AuthMode:        scheduleJob.AuthMode,
+		OAuth2:          newOAuth2(scheduleJob, secret.Redact(scheduleJob.OAuth2ClientSecret)),
+		SigningSecrets:  redactSigningSecrets(helper.DecodeSigningSecrets(scheduleJob.SigningSecrets), secret.Redact),
+		TlsProfile:      scheduleJob.TlsProfile,
+		JitterSeconds:   scheduleJob.JitterSeconds,
+		JitterMode:      scheduleJob.JitterMode,
+		TimeoutSeconds:  scheduleJob.TimeoutSeconds,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:          scheduleJob.RetryMaxAttempts,
+			InitialBackoffMs:     scheduleJob.RetryInitialBackoff,
+			Multiplier:           scheduleJob.RetryMultiplier,
+			MaxBackoffMs:         scheduleJob.RetryMaxBackoff,
+			RetryableStatusCodes: helper.SplitStatusCodes(scheduleJob.RetryStatusCodes),
+		},
+		MisfirePolicy: &MisfirePolicy{
+			Action:  scheduleJob.MisfireAction,
+			MaxRuns: scheduleJob.MisfireMaxRuns,
+		},
+		StartAt:      startAt,
+		EndAt:        endAt,
+		MaxRuns:      scheduleJob.MaxRuns,
+		PausedTime:   pausedTime,
+		Version:      scheduleJob.Version,
+		LastFireTime: formatEpochInMilli(scheduleJob.LastFireTime),
+		RunCount:     scheduleJob.RunCount,
+		CreationTime: datetime.FromUnixTime(scheduleJob.CreationTime).String(),
+		UpdateTime:   datetime.FromUnixTime(scheduleJob.UpdateTime).String(),
+	}
+}
+
+// newOAuth2 represents the OAuth2 client of a job, which has none unless it
+// authenticates by OAuth2
+func newOAuth2(scheduleJob *orm.ScheduleJob, clientSecret string) *OAuth2 {
+	if scheduleJob.AuthMode != helper.AuthModeOAuth2 {
+		return nil
+	}
+
+	return &OAuth2{
+		TokenUrl:     scheduleJob.OAuth2TokenUrl,
+		ClientID:     scheduleJob.OAuth2ClientID,
+		ClientSecret: clientSecret,
+		Scopes:       strings.Fields(scheduleJob.OAuth2Scopes),
+	}
+}
+
+// maskOAuth2 copies an OAuth2 client for logging with its secret masked
+func maskOAuth2(oauth2 *OAuth2) *OAuth2 {
+	if oauth2 == nil {
+		return nil
+	}
+
+	masked := *oauth2
+	masked.ClientSecret = secret.Mask(masked.ClientSecret)
+	return &masked
+}
+
+// redactSigningSecrets copies signing secrets with each of them hidden by
+// redact
+func redactSigningSecrets(secrets []string, redact func(string) string) []string {
+	if secrets == nil {
+		return nil
+	}
+
+	redacted := make([]string, 0, len(secrets))
+	for _, signingSecret := range secrets {
+		redacted = append(redacted, redact(signingSecret))
+	}
+
+	return redacted
+}
+
+// redactHttpHeaders copies custom headers with their values redacted, since
+// they often carry API keys
+func redactHttpHeaders(headers map[string]string, redact func(string) string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		redacted[name] = redact(value)
+	}
+
+	return redacted
+}
+
+// maskPostJobRequest copies a request for logging with its secrets masked
+func maskPostJobRequest(requestData *PostJobRequest) *PostJobRequest {
+	masked := *requestData
+	masked.JsonWebToken = secret.Mask(masked.JsonWebToken)
+	masked.HttpHeaders = redactHttpHeaders(masked.HttpHeaders, secret.Mask)
+	masked.OAuth2 = maskOAuth2(masked.OAuth2)
+	masked.SigningSecrets = redactSigningSecrets(masked.SigningSecrets, secret.Mask)
+	return &masked
+}
+
+// maskPutJobRequest copies a request for logging with its secrets masked
+func maskPutJobRequest(requestData *PutJobRequest) *PutJobRequest {
+	masked := *requestData
+	masked.JsonWebToken = secret.Mask(masked.JsonWebToken)
+	masked.HttpHeaders = redactHttpHeaders(masked.HttpHeaders, secret.Mask)
+	masked.OAuth2 = maskOAuth2(masked.OAuth2)
+	masked.SigningSecrets = redactSigningSecrets(masked.SigningSecrets, secret.Mask)
+	return &masked
+}
+
+// maskJobPatch copies a merge patch for logging with its secrets masked
+func maskJobPatch(patch map[string]interface{}) map[string]interface{} {
+	masked := map[string]interface{}{}
+	for key, value := range patch {
+		masked[key] = value
+	}
+
+	if jsonWebToken, ok := masked["jsonWebToken"].(string); ok {
+		masked["jsonWebToken"] = secret.Mask(jsonWebToken)
+	}
+
+	if httpHeaders, ok := masked["httpHeaders"].(map[string]interface{}); ok {
+		maskedHttpHeaders := map[string]interface{}{}
+		for name, value := range httpHeaders {
+			if headerValue, ok := value.(string); ok {
+				value = secret.Mask(headerValue)
+			}
+			maskedHttpHeaders[name] = value
+		}
+		masked["httpHeaders"] = maskedHttpHeaders
+	}
+
+	if oauth2, ok := masked["oauth2"].(map[string]interface{}); ok {
+		maskedOAuth2 := map[string]interface{}{}
+		for key, value := range oauth2 {
+			maskedOAuth2[key] = value
+		}
+
+		if clientSecret, ok := maskedOAuth2["clientSecret"].(string); ok {
+			maskedOAuth2["clientSecret"] = secret.Mask(clientSecret)
+		}
+		masked["oauth2"] = maskedOAuth2
+	}
+
+	if signingSecrets, ok := masked["signingSecrets"].([]interface{}); ok {
+		maskedSigningSecrets := make([]interface{}, 0, len(signingSecrets))
+		for _, value := range signingSecrets {
+			if signingSecret, ok := value.(string); ok {
+				value = secret.Mask(signingSecret)
+			}
+			maskedSigningSecrets = append(maskedSigningSecrets, value)
+		}
+		masked["signingSecrets"] = maskedSigningSecrets
+	}
+
+	return masked
+}
+
+// applyRetryPolicy copies a requested retry policy into the job, falling back to
+// default values for omitted fields
+func applyRetryPolicy(scheduleJob *orm.ScheduleJob, retryPolicy *RetryPolicy) {
+	scheduleJob.RetryMaxAttempts = helper.DefaultRetryMaxAttempts
+	scheduleJob.RetryInitialBackoff = helper.DefaultRetryInitialBackoff
+	scheduleJob.RetryMultiplier = helper.DefaultRetryMultiplier
+	scheduleJob.RetryMaxBackoff = helper.DefaultRetryMaxBackoff
+	scheduleJob.RetryStatusCodes = helper.JoinStatusCodes(helper.DefaultRetryStatusCodes)
+
+	if retryPolicy == nil {
+		return
+	}
+
+	scheduleJob.RetryMaxAttempts = retryPolicy.MaxAttempts
+	if retryPolicy.InitialBackoffMs != 0 {
+		scheduleJob.RetryInitialBackoff = retryPolicy.InitialBackoffMs
+	}
+	if retryPolicy.Multiplier != 0 {
+		scheduleJob.RetryMultiplier = retryPolicy.Multiplier
+	}
+	if retryPolicy.MaxBackoffMs != 0 {
+		scheduleJob.RetryMaxBackoff = retryPolicy.MaxBackoffMs
+	}
+	if len(retryPolicy.RetryableStatusCodes) != 0 {
+		scheduleJob.RetryStatusCodes = helper.JoinStatusCodes(retryPolicy.RetryableStatusCodes)
+	}
+}
+
+// applyMisfirePolicy copies a requested misfire policy into the job, falling
+// back to default values for omitted fields
+func applyMisfirePolicy(scheduleJob *orm.ScheduleJob, misfirePolicy *MisfirePolicy) {
+	scheduleJob.MisfireAction = helper.DefaultMisfireAction
+	scheduleJob.MisfireMaxRuns = helper.DefaultMisfireMaxRuns
+
+	if misfirePolicy == nil {
+		return
+	}
+
+	scheduleJob.MisfireAction = misfirePolicy.Action
+	if misfirePolicy.MaxRuns != 0 {
+		scheduleJob.MisfireMaxRuns = misfirePolicy.MaxRuns
+	}
+}
+
+// errJobNeverFires is returned when an enabled job has nothing left to fire
+var errJobNeverFires = errors.New("job never fires within its expression, startAt, endAt and maxRuns")
+
+// errSecretRefConflict is returned when a job carries both a JWT and a
+// reference to one
+var errSecretRefConflict = errors.New("jsonWebToken and secretRef are mutually exclusive")
+
+// errAuthorizationConflict is returned when custom headers of a job set the
+// Authorization header its auth mode sets
+var errAuthorizationConflict = errors.New("httpHeaders must not contain Authorization along with jsonWebToken, secretRef or oauth2")
+
+// applyHttpHeaders copies requested custom headers into the job, which must
+// not set the Authorization header of its auth mode. A redacted value sent
+// back as read keeps the stored value of the header.
+func applyHttpHeaders(scheduleJob *orm.ScheduleJob, headers map[string]string) error {
+	storedHeaders := helper.DecodeHttpHeaders(scheduleJob.HttpHeaders)
+
+	httpHeaders := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if strings.EqualFold(name, "Authorization") && helper.Authorizes(scheduleJob) {
+			return errAuthorizationConflict
+		}
+
+		if value == secret.Redacted {
+			storedValue, ok := storedHeaders[http.CanonicalHeaderKey(name)]
+			if !ok {
+				return fmt.Errorf("no stored value of header %q", name)
+			}
+			value = storedValue
+		}
+		httpHeaders[name] = value
+	}
+
+	var err error
+	scheduleJob.HttpHeaders, err = helper.EncodeHttpHeaders(httpHeaders)
+	return err
+}
+
+// applyAuthMode copies a requested authentication mode into the job, which
+// keeps an OAuth2 client only while it authenticates by OAuth2
+func applyAuthMode(scheduleJob *orm.ScheduleJob, authMode string, oauth2 *OAuth2) error {
+	if authMode == "" {
+		authMode = helper.AuthModeBearer
+	}
+	scheduleJob.AuthMode = authMode
+
+	if authMode != helper.AuthModeOAuth2 {
+		scheduleJob.OAuth2TokenUrl = ""
+		scheduleJob.OAuth2ClientID = ""
+		scheduleJob.OAuth2ClientSecret = ""
+		scheduleJob.OAuth2Scopes = ""
+		return nil
+	}
+
+	if oauth2 == nil {
+		return errors.New("oauth2 is required by authMode oauth2")
+	}
+	if scheduleJob.JsonWebToken != "" || scheduleJob.SecretRef != "" {
+		return errors.New("jsonWebToken and secretRef are not used by authMode oauth2")
+	}
+
+	scheduleJob.OAuth2TokenUrl = oauth2.TokenUrl
+	scheduleJob.OAuth2ClientID = oauth2.ClientID
+	// a redacted secret sent back as read keeps the stored one
+	if oauth2.ClientSecret != secret.Redacted {
+		scheduleJob.OAuth2ClientSecret = oauth2.ClientSecret
+	}
+	scheduleJob.OAuth2Scopes = strings.Join(oauth2.Scopes, " ")
+
+	if scheduleJob.OAuth2ClientSecret == "" {
+		return errors.New("oauth2 clientSecret is required")
+	}
+
+	return nil
+}
+
+// applySigningSecrets copies requested signing secrets into the job. A
+// redacted secret sent back as read keeps the stored secret at the same
+// position.
+func applySigningSecrets(scheduleJob *orm.ScheduleJob, secrets []string) error {
+	storedSecrets := helper.DecodeSigningSecrets(scheduleJob.SigningSecrets)
+
+	signingSecrets := make([]string, 0, len(secrets))
+	for i, signingSecret := range secrets {
+		if signingSecret == secret.Redacted {
+			if i >= len(storedSecrets) {
+				return fmt.Errorf("no stored signing secret at position %d", i)
+			}
+			signingSecret = storedSecrets[i]
+		}
+		signingSecrets = append(signingSecrets, signingSecret)
+	}
+
+	encoded, err := helper.EncodeSigningSecrets(signingSecrets)
+	if err != nil {
+		return err
+	}
+
+	scheduleJob.SigningSecrets = encoded
+	return nil
+}
+
+// applyTlsProfile copies a requested TLS profile into the job, which applies
+// to targets over HTTPS only
+func applyTlsProfile(scheduleJob *orm.ScheduleJob, tlsProfile string) error {
+	if tlsProfile != "" && !strings.HasPrefix(strings.ToLower(scheduleJob.HttpTargetUrl), "https://") {
+		return errors.New("tlsProfile requires an https httpTargetUrl")
+	}
+
+	scheduleJob.TlsProfile = tlsProfile
+	return nil
+}
+
+// applyJitter copies a requested jitter window and mode into the job, delaying
+// fires randomly by default
+func applyJitter(scheduleJob *orm.ScheduleJob, jitterSeconds int, jitterMode string) {
+	scheduleJob.JitterSeconds = jitterSeconds
+	scheduleJob.JitterMode = jitterMode
+	if scheduleJob.JitterMode == "" {
+		scheduleJob.JitterMode = helper.JitterModeRandom
+	}
+}
+
+// applyValidityWindow copies a requested validity window and max runs into the
+// job, leaving omitted bounds unbounded
+func applyValidityWindow(scheduleJob *orm.ScheduleJob, startAt string, endAt string, maxRuns int) error {
+	scheduleJob.StartAt = 0
+	scheduleJob.EndAt = 0
+	scheduleJob.MaxRuns = maxRuns
+
+	if startAt != "" {
+		startTime, err := time.Parse(time.RFC3339, startAt)
+		if err != nil {
+			return err
+		}
+		scheduleJob.StartAt = startTime.Unix()
+	}
+
+	if endAt != "" {
+		endTime, err := time.Parse(time.RFC3339, endAt)
+		if err != nil {
+			return err
+		}
+		scheduleJob.EndAt = endTime.Unix()
+	}
+
+	if scheduleJob.StartAt != 0 && scheduleJob.EndAt != 0 && scheduleJob.EndAt <= scheduleJob.StartAt {
+		return errors.New("endAt must be after startAt")
+	}
+
+	return nil
 }
 
 func init() {
@@ -66,8 +524,62 @@ func init() {
 		if err != nil {
 			_, err = strconv.ParseInt(expression, 10, 64)
 		}
+		if err != nil {
+			_, err = helper.ParseFireTime(expression)
+		}
 		return err == nil
 	})
+	govalidator.TagMap["timezone"] = govalidator.Validator(func(timeZone string) bool {
+		_, err := helper.LoadTimeZone(timeZone)
+		return err == nil
+	})
+	govalidator.TagMap["contenttype"] = govalidator.Validator(func(contentType string) bool {
+		return helper.ValidateContentType(contentType) == nil
+	})
+	govalidator.TagMap["secretref"] = govalidator.Validator(secret.ValidSecretRef)
+	govalidator.TagMap["tlsprofile"] = govalidator.Validator(helper.TlsProfileExists)
+	govalidator.CustomTypeTagMap.Set("signingsecrets", govalidator.CustomTypeValidator(func(i interface{}, _ interface{}) bool {
+		secrets, ok := i.([]string)
+		if !ok {
+			return false
+		}
+		// redacted secrets stand for the stored ones
+		signingSecrets := []string{}
+		for _, signingSecret := range secrets {
+			if signingSecret != secret.Redacted {
+				signingSecrets = append(signingSecrets, signingSecret)
+			}
+		}
+		return len(secrets) <= helper.MaxSigningSecrets && helper.ValidateSigningSecrets(signingSecrets) == nil
+	}))
+	govalidator.CustomTypeTagMap.Set("oauth2scopes", govalidator.CustomTypeValidator(func(i interface{}, _ interface{}) bool {
+		scopes, ok := i.([]string)
+		if !ok {
+			return false
+		}
+		for _, scope := range scopes {
+			if scope == "" || strings.ContainsAny(scope, " \t\r\n") {
+				return false
+			}
+		}
+		return true
+	}))
+	govalidator.CustomTypeTagMap.Set("httpheaders", govalidator.CustomTypeValidator(func(i interface{}, _ interface{}) bool {
+		headers, ok := i.(map[string]string)
+		return ok && helper.ValidateHttpHeaders(headers) == nil
+	}))
+	govalidator.CustomTypeTagMap.Set("httpstatuscodes", govalidator.CustomTypeValidator(func(i interface{}, _ interface{}) bool {
+		statusCodes, ok := i.([]int)
+		if !ok {
+			return false
+		}
+		for _, statusCode := range statusCodes {
+			if statusCode < 400 || statusCode > 599 {
+				return false
+			}
+		}
+		return true
+	}))
 }
 
 func PostJob(w http.ResponseWriter, r *http.Request) {
@@ -115,7 +627,7 @@ func PostJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	params["HttpBody"] = requestData
+	params["HttpBody"] = maskPostJobRequest(requestData)
 
 	now := datetime.Now()
 	jobID := utils.RandomUUIDString()
@@ -125,63 +637,96 @@ func PostJob(w http.ResponseWriter, r *http.Request) {
 		Name:            requestData.Name,
 		TriggerType:     requestData.TriggerType,
 		Expression:      requestData.Expression,
+		TimeZone:        requestData.TimeZone,
 		HttpMethod:      requestData.HttpMethod,
 		HttpTargetUrl:   requestData.HttpTargetUrl,
 		HttpRequestBody: requestData.HttpRequestBody,
+		ContentType:     requestData.ContentType,
 		JsonWebToken:    requestData.JsonWebToken,
+		SecretRef:       requestData.SecretRef,
+		TimeoutSeconds:  requestData.TimeoutSeconds,
+		Version:         1,
 		CreationTime:    now.EpochInSecond(),
 		UpdateTime:      now.EpochInSecond(),
 	}
+	if scheduleJob.TimeZone == "" {
+		scheduleJob.TimeZone = helper.DefaultTimeZone
+	}
+	if scheduleJob.ContentType == "" {
+		scheduleJob.ContentType = helper.DefaultContentType
+	}
+	if scheduleJob.TimeoutSeconds == 0 {
+		scheduleJob.TimeoutSeconds = helper.DefaultTimeoutSeconds
+	}
+	applyRetryPolicy(&scheduleJob, requestData.RetryPolicy)
+	applyMisfirePolicy(&scheduleJob, requestData.MisfirePolicy)
 
-	job, err := helper.NewJob(
-		global.Scheduler,
-		scheduleJob.JobID,
-		scheduleJob.Name,
-		scheduleJob.TriggerType,
-		scheduleJob.Expression,
-		scheduleJob.HttpMethod,
-		scheduleJob.HttpTargetUrl,
-		scheduleJob.HttpRequestBody,
-		scheduleJob.JsonWebToken,
-	)
+	err = applyValidityWindow(&scheduleJob, requestData.StartAt, requestData.EndAt, requestData.MaxRuns)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusBadRequest, errors.New("invalid argument(s): "+err.Error())),
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	if scheduleJob.JsonWebToken != "" && scheduleJob.SecretRef != "" {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errSecretRefConflict),
 		))
 		return
 	}
-	scheduleJob.JobKey = job.Key()
 
-	// insert job into database
-	stmt1, err := dbx.New().Prepare(`
-		INSERT INTO schedule_jobs (JobID,JobKey,Status,Name,TriggerType,Expression,HttpMethod,HttpTargetUrl,HttpRequestBody,JsonWebToken,CreationTime,UpdateTime) 
-		VALUES (?,?,?,?,?,?,?,?,?,?,?,?)
-		;
-	`)
+	err = applyAuthMode(&scheduleJob, requestData.AuthMode, requestData.OAuth2)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
 
+	err = applySigningSecrets(&scheduleJob, requestData.SigningSecrets)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusBadRequest, err),
 		))
 		return
 	}
-	defer stmt1.Close()
 
-	_, err = stmt1.Exec(
-		scheduleJob.JobID,
-		scheduleJob.JobKey,
-		scheduleJob.Status,
-		scheduleJob.Name,
-		scheduleJob.TriggerType,
-		scheduleJob.Expression,
-		scheduleJob.HttpMethod,
-		scheduleJob.HttpTargetUrl,
-		scheduleJob.HttpRequestBody,
-		scheduleJob.JsonWebToken,
-		scheduleJob.CreationTime,
-		scheduleJob.UpdateTime,
-	)
+	err = applyTlsProfile(&scheduleJob, requestData.TlsProfile)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+	applyJitter(&scheduleJob, requestData.JitterSeconds, requestData.JitterMode)
+
+	if helper.JobExpired(&scheduleJob, now.GetTime()) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errJobNeverFires),
+		))
+		return
+	}
+
+	err = applyHttpHeaders(&scheduleJob, requestData.HttpHeaders)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
 
+	job, err := helper.PrepareJob(scheduleJob)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("invalid argument(s): "+err.Error())),
+		))
+		return
+	}
+	scheduleJob.JobKey = job.Key()
+
+	// insert job into database before it is able to fire
+	err = store.New().Create(&scheduleJob)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -189,21 +734,22 @@ func PostJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultObject.Data = &GetJobResult{
-		JobID:           scheduleJob.JobID,
-		JobKey:          scheduleJob.JobKey,
-		Status:          scheduleJob.Status,
-		Name:            scheduleJob.Name,
-		TriggerType:     scheduleJob.TriggerType,
-		Expression:      scheduleJob.Expression,
-		HttpMethod:      scheduleJob.HttpMethod,
-		HttpTargetUrl:   scheduleJob.HttpTargetUrl,
-		HttpRequestBody: scheduleJob.HttpRequestBody,
-		JsonWebToken:    scheduleJob.JsonWebToken,
-		CreationTime:    datetime.FromUnixTime(scheduleJob.CreationTime).String(),
-		UpdateTime:      datetime.FromUnixTime(scheduleJob.UpdateTime).String(),
+	err = job.Schedule(global.Scheduler)
+	if err != nil {
+		// leave no row behind for a job that never fires
+		if deleteErr := store.New().Delete(scheduleJob.JobID); deleteErr != nil {
+			logger.New().Error("FAILED TO DELETE UNSCHEDULED JOB", zap.String("JobID", scheduleJob.JobID), zap.Error(deleteErr))
+		}
+
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
 	}
 
+	resultObject.Data = newGetJobResult(&scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
+
 	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
 	result, err := json.Marshal(resultObject)
 	if nil != err {
@@ -214,6 +760,145 @@ func PostJob(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// filterable columns of jobs matched by equality, keyed by query name
+var jobEqualityFilters = map[string]string{
+	"status":      "Status",
+	"triggerType": "TriggerType",
+	"httpMethod":  "HttpMethod",
+}
+
+// filterable time ranges of jobs (inclusive), keyed by query name
+var jobRangeFilters = map[string]store.JobCondition{
+	"creationTimeFrom": {Column: "CreationTime", Operator: store.OperatorFrom},
+	"creationTimeTo":   {Column: "CreationTime", Operator: store.OperatorTo},
+	"updateTimeFrom":   {Column: "UpdateTime", Operator: store.OperatorFrom},
+	"updateTimeTo":     {Column: "UpdateTime", Operator: store.OperatorTo},
+}
+
+// sortable columns of jobs, keyed by query name
+var jobSortColumns = map[string]string{
+	"name":         "Name",
+	"status":       "Status",
+	"triggerType":  "TriggerType",
+	"httpMethod":   "HttpMethod",
+	"creationTime": "CreationTime",
+	"updateTime":   "UpdateTime",
+}
+
+// parseQueryTime parses either epoch in second or an RFC 3339 timestamp
+func parseQueryTime(value string) (int64, error) {
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return epoch, nil
+	}
+
+	timeObject, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return timeObject.Unix(), nil
+}
+
+// newJobQueryConditions builds the conditions of listing jobs from the
+// "filter=column:value" query parameters. Values of the same equality column
+// are OR-ed, everything else is AND-ed.
+func newJobQueryConditions(query url.Values) ([]store.JobCondition, error) {
+	conditions := []store.JobCondition{}
+
+	equalityColumns := []string{}
+	equalityValues := map[string][]interface{}{}
+
+	for _, filter := range GetFiltersFromQuery(query, "filter") {
+		if column, ok := jobEqualityFilters[filter.Column]; ok {
+			var value interface{} = filter.Value
+			if column == "Status" {
+				status, err := strconv.Atoi(filter.Value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid filter value %q of %s", filter.Value, filter.Column)
+				}
+				value = status
+			}
+
+			if _, ok := equalityValues[column]; !ok {
+				equalityColumns = append(equalityColumns, column)
+			}
+			equalityValues[column] = append(equalityValues[column], value)
+			continue
+		}
+
+		if condition, ok := jobRangeFilters[filter.Column]; ok {
+			epoch, err := parseQueryTime(filter.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter value %q of %s", filter.Value, filter.Column)
+			}
+
+			condition.Values = []interface{}{epoch}
+			conditions = append(conditions, condition)
+			continue
+		}
+
+		if filter.Column == "name" {
+			// name filters by prefix
+			conditions = append(conditions, store.JobCondition{
+				Column:   "Name",
+				Operator: store.OperatorPrefix,
+				Values:   []interface{}{filter.Value},
+			})
+			continue
+		}
+
+		return nil, fmt.Errorf("unknown filter column %s", filter.Column)
+	}
+
+	for _, column := range equalityColumns {
+		conditions = append(conditions, store.JobCondition{
+			Column:   column,
+			Operator: store.OperatorIn,
+			Values:   equalityValues[column],
+		})
+	}
+
+	return conditions, nil
+}
+
+// newJobQueryOrders builds the orders of listing jobs from the
+// "sort=column:asc|desc" query parameters. Ties are broken by JobID so that
+// paging stays stable.
+func newJobQueryOrders(query url.Values) ([]store.JobOrder, error) {
+	orders := []store.JobOrder{}
+
+	for _, value := range GetStringArrayFromQuery(query, "sort") {
+		for _, token := range strings.Split(value, ",") {
+			tokens := strings.SplitN(strings.TrimSpace(token), ":", 2)
+
+			column, ok := jobSortColumns[tokens[0]]
+			if !ok {
+				return nil, fmt.Errorf("unknown sort column %s", tokens[0])
+			}
+
+			descending := false
+			if len(tokens) == 2 {
+				switch strings.ToLower(tokens[1]) {
+				case "asc":
+				case "desc":
+					descending = true
+				default:
+					return nil, fmt.Errorf("unknown sort direction %s", tokens[1])
+				}
+			}
+
+			orders = append(orders, store.JobOrder{Column: column, Descending: descending})
+		}
+	}
+
+	if len(orders) == 0 {
+		orders = append(orders, store.JobOrder{Column: "CreationTime"})
+	}
+	orders = append(orders, store.JobOrder{Column: "JobID"})
+
+	return orders, nil
+}
+
 func GetJobs(w http.ResponseWriter, r *http.Request) {
 	var (
 		params       = map[string]interface{}{}
@@ -225,36 +910,81 @@ func GetJobs(w http.ResponseWriter, r *http.Request) {
 	size := GetIntFromQuery(query, "size", 0)
 	params["From"] = from
 	params["Size"] = size
+	params["Filter"] = GetStringArrayFromQuery(query, "filter")
+	params["Sort"] = GetStringArrayFromQuery(query, "sort")
+	params["Q"] = query.Get("q")
 
-	withLimit := false
-	if size != 0 {
-		withLimit = true
+	conditions, err := newJobQueryConditions(query)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
 	}
 
-	stmt1, err := dbx.New().Prepare(`
-		SELECT COUNT(*) AS TotalCount 
-		FROM schedule_jobs
-		;
-	`)
+	orders, err := newJobQueryOrders(query)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusBadRequest, err),
 		))
 		return
 	}
-	defer stmt1.Close()
 
-	rows1, err := stmt1.Query()
+	scheduleJobs, totalCount, err := store.New().List(&store.JobQuery{
+		Conditions: conditions,
+		Search:     strings.TrimSpace(query.Get("q")),
+		Orders:     orders,
+		From:       from,
+		Size:       size,
+	})
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
 		))
 		return
 	}
-	defer rows1.Close()
 
-	totalCount := 0
-	err = scan.Row(&totalCount, rows1)
+	entities := []*GetJobResult{}
+	for _, scheduleJob := range scheduleJobs {
+		entities = append(entities, newGetJobResult(&scheduleJob))
+	}
+
+	resultObject.Meta = &model.Meta{
+		From:  from,
+		Size:  len(entities),
+		Total: totalCount,
+	}
+	resultObject.Data = entities
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func GetJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	params["JobID"] = jobID
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -262,67 +992,220 @@ func GetJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	arguments2 := []interface{}{}
-	stmt2String := `
-		SELECT * 
-		FROM schedule_jobs 
-	`
-	if withLimit {
-		stmt2String += `LIMIT ?,?`
-		arguments2 = append(arguments2, from, size)
+	resultObject.Data = newGetJobResult(scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+// updateJob replaces the settings of a job with the requested ones and
+// reschedules it. The row is only updated while its version is unchanged.
+func updateJob(scheduleJob *orm.ScheduleJob, requestData *PutJobRequest) (int, error) {
+	// keep the time a job got disabled, so that resuming it is able to catch up
+	if requestData.Status == orm.JobStatusDisable {
+		if scheduleJob.Status != orm.JobStatusDisable {
+			scheduleJob.PausedTime = datetime.Now().EpochInSecond()
+		}
+	} else {
+		scheduleJob.PausedTime = 0
+	}
+
+	scheduleJob.Status = requestData.Status
+	scheduleJob.Name = requestData.Name
+	scheduleJob.TriggerType = requestData.TriggerType
+	scheduleJob.Expression = requestData.Expression
+	scheduleJob.TimeZone = requestData.TimeZone
+	if scheduleJob.TimeZone == "" {
+		scheduleJob.TimeZone = helper.DefaultTimeZone
+	}
+	scheduleJob.HttpMethod = requestData.HttpMethod
+	scheduleJob.HttpTargetUrl = requestData.HttpTargetUrl
+	scheduleJob.HttpRequestBody = requestData.HttpRequestBody
+	scheduleJob.ContentType = requestData.ContentType
+	if scheduleJob.ContentType == "" {
+		scheduleJob.ContentType = helper.DefaultContentType
+	}
+	// a redacted secret sent back as read keeps the stored one
+	if requestData.JsonWebToken != secret.Redacted {
+		scheduleJob.JsonWebToken = requestData.JsonWebToken
+	}
+	scheduleJob.SecretRef = requestData.SecretRef
+	if scheduleJob.JsonWebToken != "" && scheduleJob.SecretRef != "" {
+		return http.StatusBadRequest, errSecretRefConflict
+	}
+
+	err := applyAuthMode(scheduleJob, requestData.AuthMode, requestData.OAuth2)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	err = applySigningSecrets(scheduleJob, requestData.SigningSecrets)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	err = applyTlsProfile(scheduleJob, requestData.TlsProfile)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	applyJitter(scheduleJob, requestData.JitterSeconds, requestData.JitterMode)
+	scheduleJob.TimeoutSeconds = requestData.TimeoutSeconds
+	if scheduleJob.TimeoutSeconds == 0 {
+		scheduleJob.TimeoutSeconds = helper.DefaultTimeoutSeconds
+	}
+	scheduleJob.UpdateTime = datetime.Now().EpochInSecond()
+	scheduleJob.Version++
+	applyRetryPolicy(scheduleJob, requestData.RetryPolicy)
+	applyMisfirePolicy(scheduleJob, requestData.MisfirePolicy)
+
+	err = applyValidityWindow(scheduleJob, requestData.StartAt, requestData.EndAt, requestData.MaxRuns)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if requestData.Status == orm.JobStatusEnable && helper.JobExpired(scheduleJob, time.Now()) {
+		return http.StatusBadRequest, errJobNeverFires
+	}
+
+	err = applyHttpHeaders(scheduleJob, requestData.HttpHeaders)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	jobKey := scheduleJob.JobKey
+	scheduleJob.JobKey = -1
+
+	var job *helper.PreparedJob
+	if requestData.Status == 1 {
+		job, err = helper.PrepareJob(*scheduleJob)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		scheduleJob.JobKey = job.Key()
+	}
+
+	// concurrent updates of the job are applied to the scheduler one at a time
+	defer helper.LockJob(scheduleJob.JobID)()
+
+	// the existing job stays scheduled unless the conditional update takes
+	// effect
+	err = store.New().Update(scheduleJob)
+	if err == store.ErrJobVersionConflict {
+		return http.StatusPreconditionFailed, err
+	}
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	// try to destory existing job
+	err = helper.UnscheduleJob(global.Scheduler, jobKey)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if job != nil {
+		// restore the job
+		err = job.Schedule(global.Scheduler)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+func PutJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	// receive post data
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	// deserialize data
+	requestObject := model.Request{
+		Desire: &PutJobRequest{},
+		Data:   nil,
+	}
+
+	err = json.Unmarshal(body, &requestObject)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	requestData, ok := requestObject.Desire.(*PutJobRequest)
+	if !ok {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("unexpected request data")),
+		))
+		return
+	}
+
+	params["JobID"] = jobID
+	params["HttpBody"] = maskPutJobRequest(requestData)
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	_, err = govalidator.ValidateStruct(requestData)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
 	}
 
-	stmt2, err := dbx.New().Prepare(stmt2String)
+	// query schedule job row
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
 		))
 		return
 	}
-	defer stmt2.Close()
 
-	rows2, err := stmt2.Query(arguments2...)
-	if err != nil {
+	if !matchETag(r.Header.Get("If-Match"), jobETag(scheduleJob.Version)) {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusPreconditionFailed, store.ErrJobVersionConflict),
 		))
 		return
 	}
-	defer rows2.Close()
 
-	scheduleJobs := []orm.ScheduleJob{}
-	err = scan.Rows(&scheduleJobs, rows2)
+	statusCode, err := updateJob(scheduleJob, requestData)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, statusCode, err),
 		))
 		return
 	}
 
-	entities := []*GetJobResult{}
-	for _, scheduleJob := range scheduleJobs {
-		entities = append(entities, &GetJobResult{
-			JobID:           scheduleJob.JobID,
-			JobKey:          scheduleJob.JobKey,
-			Status:          scheduleJob.Status,
-			Name:            scheduleJob.Name,
-			TriggerType:     scheduleJob.TriggerType,
-			Expression:      scheduleJob.Expression,
-			HttpMethod:      scheduleJob.HttpMethod,
-			HttpTargetUrl:   scheduleJob.HttpTargetUrl,
-			HttpRequestBody: scheduleJob.HttpRequestBody,
-			JsonWebToken:    scheduleJob.JsonWebToken,
-			CreationTime:    datetime.FromUnixTime(scheduleJob.CreationTime).String(),
-			UpdateTime:      datetime.FromUnixTime(scheduleJob.UpdateTime).String(),
-		})
-	}
-
-	resultObject.Meta = &model.Meta{
-		From:  from,
-		Size:  len(entities),
-		Total: totalCount,
-	}
-	resultObject.Data = entities
+	resultObject.Data = newGetJobResult(scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
 
 	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
 	result, err := json.Marshal(resultObject)
@@ -334,82 +1217,87 @@ func GetJobs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func GetJob(w http.ResponseWriter, r *http.Request) {
-	var (
-		params       = map[string]interface{}{}
-		resultObject = model.Response{}
-	)
-
-	vars := mux.Vars(r)
-	jobID := vars["jobID"]
-
-	params["JobID"] = jobID
+// newPutJobRequest represents the current settings of a job as a full update
+// request, which a merge patch applies to
+func newPutJobRequest(scheduleJob *orm.ScheduleJob) *PutJobRequest {
+	return &PutJobRequest{
+		Status:          scheduleJob.Status,
+		Name:            scheduleJob.Name,
+		TriggerType:     scheduleJob.TriggerType,
+		Expression:      scheduleJob.Expression,
+		TimeZone:        scheduleJob.TimeZone,
+		HttpMethod:      scheduleJob.HttpMethod,
+		HttpTargetUrl:   scheduleJob.HttpTargetUrl,
+		HttpRequestBody: scheduleJob.HttpRequestBody,
+		HttpHeaders:     helper.DecodeHttpHeaders(scheduleJob.HttpHeaders),
+		ContentType:     scheduleJob.ContentType,
+		JsonWebToken:    scheduleJob.JsonWebToken,
+		SecretRef:       scheduleJob.SecretRef,
+		AuthMode:        scheduleJob.AuthMode,
+		OAuth2:          newOAuth2(scheduleJob, scheduleJob.OAuth2ClientSecret),
+		SigningSecrets:  helper.DecodeSigningSecrets(scheduleJob.SigningSecrets),
+		TlsProfile:      scheduleJob.TlsProfile,
+		JitterSeconds:   scheduleJob.JitterSeconds,
+		JitterMode:      scheduleJob.JitterMode,
+		TimeoutSeconds:  scheduleJob.TimeoutSeconds,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:          scheduleJob.RetryMaxAttempts,
+			InitialBackoffMs:     scheduleJob.RetryInitialBackoff,
+			Multiplier:           scheduleJob.RetryMultiplier,
+			MaxBackoffMs:         scheduleJob.RetryMaxBackoff,
+			RetryableStatusCodes: helper.SplitStatusCodes(scheduleJob.RetryStatusCodes),
+		},
+		MisfirePolicy: &MisfirePolicy{
+			Action:  scheduleJob.MisfireAction,
+			MaxRuns: scheduleJob.MisfireMaxRuns,
+		},
+		StartAt: formatRFC3339(scheduleJob.StartAt),
+		EndAt:   formatRFC3339(scheduleJob.EndAt),
+		MaxRuns: scheduleJob.MaxRuns,
+	}
+}
 
-	if !govalidator.IsUUIDv4(jobID) {
-		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
-		))
-		return
+// formatRFC3339 formats epoch in second the way requests carry it, leaving
+// unset times empty
+func formatRFC3339(epoch int64) string {
+	if epoch == 0 {
+		return ""
 	}
 
-	stmt1, err := dbx.New().Prepare(`
-		SELECT * 
-		FROM schedule_jobs 
-		WHERE JobID=?
-		;
-	`)
+	return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+}
+
+// applyJobPatch applies a JSON merge patch (RFC 7386) to the settings of a job
+func applyJobPatch(scheduleJob *orm.ScheduleJob, patch map[string]interface{}) (*PutJobRequest, error) {
+	current, err := json.Marshal(newPutJobRequest(scheduleJob))
 	if err != nil {
-		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
-		))
-		return
+		return nil, err
 	}
-	defer stmt1.Close()
 
-	rows1, err := stmt1.Query(jobID)
+	target := map[string]interface{}{}
+	err = json.Unmarshal(current, &target)
 	if err != nil {
-		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
-		))
-		return
+		return nil, err
 	}
-	defer rows1.Close()
 
-	scheduleJob := orm.ScheduleJob{}
-	err = scan.Row(&scheduleJob, rows1)
+	merged, err := json.Marshal(mergePatch(target, patch))
 	if err != nil {
-		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
-		))
-		return
+		return nil, err
 	}
 
-	resultObject.Data = &GetJobResult{
-		JobID:           scheduleJob.JobID,
-		JobKey:          scheduleJob.JobKey,
-		Status:          scheduleJob.Status,
-		Name:            scheduleJob.Name,
-		TriggerType:     scheduleJob.TriggerType,
-		Expression:      scheduleJob.Expression,
-		HttpMethod:      scheduleJob.HttpMethod,
-		HttpTargetUrl:   scheduleJob.HttpTargetUrl,
-		HttpRequestBody: scheduleJob.HttpRequestBody,
-		JsonWebToken:    scheduleJob.JsonWebToken,
-		CreationTime:    datetime.FromUnixTime(scheduleJob.CreationTime).String(),
-		UpdateTime:      datetime.FromUnixTime(scheduleJob.UpdateTime).String(),
-	}
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
 
-	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
-	result, err := json.Marshal(resultObject)
-	if nil != err {
-		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
-	} else {
-		w.Header().Add("Content-Type", "application/json")
-		fmt.Fprintln(w, string(result))
+	requestData := &PutJobRequest{}
+	err = decoder.Decode(requestData)
+	if err != nil {
+		return nil, err
 	}
+
+	return requestData, nil
 }
 
-func PutJob(w http.ResponseWriter, r *http.Request) {
+func PatchJob(w http.ResponseWriter, r *http.Request) {
 	var (
 		params       = map[string]interface{}{}
 		resultObject = model.Response{}
@@ -427,9 +1315,9 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// deserialize data
+	// deserialize data, the desire being a merge patch of PutJobRequest
 	requestObject := model.Request{
-		Desire: &PutJobRequest{},
+		Desire: &map[string]interface{}{},
 		Data:   nil,
 	}
 
@@ -441,8 +1329,8 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	requestData, ok := requestObject.Desire.(*PutJobRequest)
-	if !ok {
+	patch, ok := requestObject.Desire.(*map[string]interface{})
+	if !ok || *patch == nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusBadRequest, errors.New("unexpected request data")),
 		))
@@ -450,7 +1338,7 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	params["JobID"] = jobID
-	params["HttpBody"] = requestData
+	params["HttpBody"] = maskJobPatch(*patch)
 
 	if !govalidator.IsUUIDv4(jobID) {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
@@ -459,129 +1347,74 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err = govalidator.ValidateStruct(requestData)
+	// query schedule job row
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusBadRequest, err),
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
 		))
 		return
 	}
 
-	// query schedule job row
-	stmt1, err := dbx.New().Prepare(`
-		SELECT * 
-		FROM schedule_jobs 
-		WHERE JobID=?
-		;
-	`)
-	if err != nil {
+	if !matchETag(r.Header.Get("If-Match"), jobETag(scheduleJob.Version)) {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusPreconditionFailed, store.ErrJobVersionConflict),
 		))
 		return
 	}
-	defer stmt1.Close()
 
-	rows1, err := stmt1.Query(jobID)
+	requestData, err := applyJobPatch(scheduleJob, *patch)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusBadRequest, err),
 		))
 		return
 	}
-	defer rows1.Close()
 
-	scheduleJob := orm.ScheduleJob{}
-	err = scan.Row(&scheduleJob, rows1)
+	// fields left out of the patch keep their stored values, so that this
+	// effectively validates the supplied ones. A done job stays done unless
+	// the patch sets its status, which is then left out of validation.
+	validated := *requestData
+	if _, ok := (*patch)["status"]; !ok && scheduleJob.Status == orm.JobStatusDone {
+		validated.Status = orm.JobStatusDisable
+	}
+	_, err = govalidator.ValidateStruct(&validated)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusBadRequest, err),
 		))
 		return
 	}
 
-	// try to destory existing job
-	_, err = global.Scheduler.GetScheduledJob(scheduleJob.JobKey)
-	if err == nil {
-		err = global.Scheduler.DeleteJob(scheduleJob.JobKey)
-		if err != nil {
-			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-				responseError(w, &resultObject, http.StatusInternalServerError, err),
-			))
-			return
-		}
-	}
-	scheduleJob.JobKey = -1
-
-	if requestData.Status == 1 {
-		// restore the job
-		job, err := helper.NewJob(
-			global.Scheduler,
-			scheduleJob.JobID,
-			requestData.Name,
-			requestData.TriggerType,
-			requestData.Expression,
-			requestData.HttpMethod,
-			requestData.HttpTargetUrl,
-			requestData.HttpRequestBody,
-			requestData.JsonWebToken,
-		)
-		if err != nil {
-			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-				responseError(w, &resultObject, http.StatusInternalServerError, err),
-			))
-			return
-		}
-
-		scheduleJob.JobKey = job.Key()
-	}
-
-	scheduleJob.Status = requestData.Status
-	scheduleJob.Name = requestData.Name
-	scheduleJob.TriggerType = requestData.TriggerType
-	scheduleJob.Expression = requestData.Expression
-	scheduleJob.HttpMethod = requestData.HttpMethod
-	scheduleJob.HttpTargetUrl = requestData.HttpTargetUrl
-	scheduleJob.HttpRequestBody = requestData.HttpRequestBody
-	scheduleJob.JsonWebToken = requestData.JsonWebToken
-	scheduleJob.UpdateTime = datetime.Now().EpochInSecond()
-
-	stmt2, err := dbx.New().Prepare(`
-		UPDATE schedule_jobs SET 
-		JobKey=?,
-		Status=?,
-		Name=?,
-		TriggerType=?,
-		Expression=?,
-		HttpMethod=?,
-		HttpTargetUrl=?,
-		HttpRequestBody=?,
-		JsonWebToken=?,
-		UpdateTime=? 
-		WHERE JobID=?
-		;
-	`)
+	statusCode, err := updateJob(scheduleJob, requestData)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, statusCode, err),
 		))
 		return
 	}
-	defer stmt2.Close()
 
-	_, err = stmt2.Exec(
-		scheduleJob.JobKey,
-		scheduleJob.Status,
-		scheduleJob.Name,
-		scheduleJob.TriggerType,
-		scheduleJob.Expression,
-		scheduleJob.HttpMethod,
-		scheduleJob.HttpTargetUrl,
-		scheduleJob.HttpRequestBody,
-		scheduleJob.JsonWebToken,
-		scheduleJob.UpdateTime,
-		scheduleJob.JobID,
+	resultObject.Data = newGetJobResult(scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func DeleteJobs(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
 	)
+
+	// delete rows along with execution history
+	err := store.New().DeleteAll()
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -589,20 +1422,8 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resultObject.Data = &GetJobResult{
-		JobID:           scheduleJob.JobID,
-		JobKey:          scheduleJob.JobKey,
-		Status:          scheduleJob.Status,
-		Name:            scheduleJob.Name,
-		TriggerType:     scheduleJob.TriggerType,
-		Expression:      scheduleJob.Expression,
-		HttpMethod:      scheduleJob.HttpMethod,
-		HttpTargetUrl:   scheduleJob.HttpTargetUrl,
-		HttpRequestBody: scheduleJob.HttpRequestBody,
-		JsonWebToken:    scheduleJob.JsonWebToken,
-		CreationTime:    datetime.FromUnixTime(scheduleJob.CreationTime).String(),
-		UpdateTime:      datetime.FromUnixTime(scheduleJob.UpdateTime).String(),
-	}
+	// destory existing jobs along with their fires waiting out their jitter
+	helper.ClearJobs(global.Scheduler)
 
 	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
 	result, err := json.Marshal(resultObject)
@@ -614,25 +1435,39 @@ func PutJob(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func DeleteJobs(w http.ResponseWriter, r *http.Request) {
+func DeleteJob(w http.ResponseWriter, r *http.Request) {
 	var (
 		params       = map[string]interface{}{}
 		resultObject = model.Response{}
 	)
 
-	// delete row
-	stmt1, err := dbx.New().Prepare(`
-		TRUNCATE TABLE schedule_jobs
-	`)
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	params["JobID"] = jobID
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	// the job is read while no update of it is applied to the scheduler, so
+	// that the job it is scheduled by is the one unscheduled
+	defer helper.LockJob(jobID)()
+
+	// query schedule job row
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
 		))
 		return
 	}
-	defer stmt1.Close()
 
-	_, err = stmt1.Exec()
+	// try to destory existing job
+	err = helper.UnscheduleJob(global.Scheduler, scheduleJob.JobKey)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -640,8 +1475,14 @@ func DeleteJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// destory existing jobs
-	global.Scheduler.Clear()
+	// delete row along with execution history
+	err = store.New().Delete(scheduleJob.JobID)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
 
 	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
 	result, err := json.Marshal(resultObject)
@@ -653,7 +1494,12 @@ func DeleteJobs(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func DeleteJob(w http.ResponseWriter, r *http.Request) {
+// jobETag returns the entity tag of a job version
+func jobETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+func PauseJob(w http.ResponseWriter, r *http.Request) {
 	var (
 		params       = map[string]interface{}{}
 		resultObject = model.Response{}
@@ -672,42 +1518,56 @@ func DeleteJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// query schedule job row
-	stmt1, err := dbx.New().Prepare(`
-		SELECT * 
-		FROM schedule_jobs 
-		WHERE JobID=?
-		;
-	`)
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
 		))
 		return
 	}
-	defer stmt1.Close()
 
-	rows1, err := stmt1.Query(jobID)
-	if err != nil {
+	if !matchETag(r.Header.Get("If-Match"), jobETag(scheduleJob.Version)) {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusPreconditionFailed, store.ErrJobVersionConflict),
 		))
 		return
 	}
-	defer rows1.Close()
 
-	scheduleJob := orm.ScheduleJob{}
-	err = scan.Row(&scheduleJob, rows1)
-	if err != nil {
+	switch scheduleJob.Status {
+	case orm.JobStatusDone:
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusConflict, errors.New("job is done")),
 		))
 		return
-	}
+	case orm.JobStatusEnable:
+		jobKey := scheduleJob.JobKey
 
-	// try to destory existing job
-	_, err = global.Scheduler.GetScheduledJob(scheduleJob.JobKey)
-	if err == nil {
-		err = global.Scheduler.DeleteJob(scheduleJob.JobKey)
+		now := datetime.Now()
+		scheduleJob.JobKey = -1
+		scheduleJob.Status = orm.JobStatusDisable
+		scheduleJob.PausedTime = now.EpochInSecond()
+		scheduleJob.UpdateTime = now.EpochInSecond()
+		scheduleJob.Version++
+
+		defer helper.LockJob(scheduleJob.JobID)()
+
+		// the job stays scheduled unless the conditional update takes effect
+		err = store.New().UpdateState(scheduleJob)
+		if err == store.ErrJobVersionConflict {
+			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+				responseError(w, &resultObject, http.StatusPreconditionFailed, err),
+			))
+			return
+		}
+		if err != nil {
+			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+				responseError(w, &resultObject, http.StatusInternalServerError, err),
+			))
+			return
+		}
+
+		// try to destory existing job
+		err = helper.UnscheduleJob(global.Scheduler, jobKey)
 		if err != nil {
 			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 				responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -716,21 +1576,42 @@ func DeleteJob(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// delete row
-	stmt2, err := dbx.New().Prepare(`
-		DELETE  
-		FROM schedule_jobs 
-		WHERE JobID=?
-	`)
-	if err != nil {
+	resultObject.Data = newGetJobResult(scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func ResumeJob(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	query := r.URL.Query()
+	catchUp := GetBoolFromQuery(query, "catchUp", false)
+	params["JobID"] = jobID
+	params["CatchUp"] = catchUp
+
+	if !govalidator.IsUUIDv4(jobID) {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
-			responseError(w, &resultObject, http.StatusInternalServerError, err),
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
 		))
 		return
 	}
-	defer stmt2.Close()
 
-	_, err = stmt2.Exec(scheduleJob.JobID)
+	// query schedule job row
+	scheduleJob, err := store.New().Get(jobID)
 	if err != nil {
 		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
 			responseError(w, &resultObject, http.StatusInternalServerError, err),
@@ -738,6 +1619,100 @@ func DeleteJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !matchETag(r.Header.Get("If-Match"), jobETag(scheduleJob.Version)) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusPreconditionFailed, store.ErrJobVersionConflict),
+		))
+		return
+	}
+
+	switch scheduleJob.Status {
+	case orm.JobStatusDone:
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusConflict, errors.New("job is done")),
+		))
+		return
+	case orm.JobStatusDisable:
+		now := datetime.Now()
+
+		// fire once for the runs missed while paused, on behalf of the latest one
+		var (
+			scheduledTime time.Time
+			missed        bool
+		)
+		if catchUp && scheduleJob.PausedTime != 0 {
+			scheduledTime, missed, err = helper.LastMissedFireTime(scheduleJob, time.Unix(scheduleJob.PausedTime, 0), now.GetTime())
+			if err != nil {
+				logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+					responseError(w, &resultObject, http.StatusInternalServerError, err),
+				))
+				return
+			}
+		}
+
+		// the scheduled job carries the version the job is updated to
+		scheduleJob.Status = orm.JobStatusEnable
+		scheduleJob.PausedTime = 0
+		scheduleJob.UpdateTime = now.EpochInSecond()
+		scheduleJob.Version++
+
+		var job *helper.PreparedJob
+		if helper.JobExpired(scheduleJob, now.GetTime()) {
+			// the job may have nothing left to fire after being paused, such
+			// as an at job past due
+			scheduleJob.Status = orm.JobStatusDone
+		} else {
+			job, err = helper.PrepareJob(*scheduleJob)
+			if err != nil {
+				logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+					responseError(w, &resultObject, http.StatusInternalServerError, err),
+				))
+				return
+			}
+
+			scheduleJob.JobKey = job.Key()
+		}
+
+		defer helper.LockJob(scheduleJob.JobID)()
+
+		// neither catch-up nor the job takes effect unless the conditional
+		// update does
+		err = store.New().UpdateState(scheduleJob)
+		if err == store.ErrJobVersionConflict {
+			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+				responseError(w, &resultObject, http.StatusPreconditionFailed, err),
+			))
+			return
+		}
+		if err != nil {
+			logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+				responseError(w, &resultObject, http.StatusInternalServerError, err),
+			))
+			return
+		}
+
+		if missed {
+			// the fire may have been delivered by another replica already
+			if execution := helper.CatchUpJob(*scheduleJob, scheduledTime); execution != nil {
+				params["ExecutionID"] = execution.ExecutionID
+			}
+		}
+
+		if job != nil {
+			// restore the job
+			err = job.Schedule(global.Scheduler)
+			if err != nil {
+				logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+					responseError(w, &resultObject, http.StatusInternalServerError, err),
+				))
+				return
+			}
+		}
+	}
+
+	resultObject.Data = newGetJobResult(scheduleJob)
+	w.Header().Set("ETag", jobETag(scheduleJob.Version))
+
 	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
 	result, err := json.Marshal(resultObject)
 	if nil != err {
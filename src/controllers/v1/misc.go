@@ -69,7 +69,8 @@ func GetFiltersFromQuery(query url.Values, key string) []QueryFilter {
 	filters := []QueryFilter{}
 
 	for _, value := range values {
-		tokens := strings.Split(value, ":")
+		// split at the first colon only, so that values may contain colons
+		tokens := strings.SplitN(value, ":", 2)
 		if len(tokens) != 2 {
 			continue
 		}
@@ -82,3 +83,44 @@ func GetFiltersFromQuery(query url.Values, key string) []QueryFilter {
 
 	return filters
 }
+
+// matchETag evaluates an If-Match header against the current entity tag.
+// An absent header always matches.
+func matchETag(ifMatch string, etag string) bool {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+
+	// If-Match uses the strong comparison, so weak tags never match
+	for _, tag := range strings.Split(ifMatch, ",") {
+		if strings.TrimSpace(tag) == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to a decoded JSON document
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
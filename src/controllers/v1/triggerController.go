@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/asaskevich/govalidator"
+	"github.com/cloud01-wu/cgsl/datetime"
+	"github.com/cloud01-wu/cgsl/httpx/model"
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/cgsl/utils"
+	"github.com/cloud01-wu/scheduler/global"
+	"github.com/cloud01-wu/scheduler/helper"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// default number of fire times a preview returns
+const defaultPreviewCount = 10
+
+type PreviewTriggerRequest struct {
+	TriggerType string `json:"triggerType" valid:"in(cron|interval|once|at)"`
+	Expression  string `json:"expression" valid:"expression~expression does not validate as specific cron/interval/once/at expression. See https://github.com/reugn/go-quartz"`
+	TimeZone    string `json:"timeZone" valid:"timezone~timeZone does not validate as IANA time zone name,optional"`
+	Count       int    `json:"count" valid:"range(1|100)~count must be between 1 and 100,optional"`
+}
+
+type PreviewTriggerResult struct {
+	TriggerType string   `json:"triggerType"`
+	Expression  string   `json:"expression"`
+	TimeZone    string   `json:"timeZone"`
+	NextRuns    []string `json:"nextRuns"`
+}
+
+// newPreviewTriggerResult formats fire times in the time zone of the trigger
+func newPreviewTriggerResult(triggerType string, expression string, timeZone string, fireTimes []time.Time) *PreviewTriggerResult {
+	if timeZone == "" {
+		timeZone = helper.DefaultTimeZone
+	}
+
+	location, err := helper.LoadTimeZone(timeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	nextRuns := []string{}
+	for _, fireTime := range fireTimes {
+		nextRuns = append(nextRuns, datetime.FromTime(fireTime.In(location)).String())
+	}
+
+	return &PreviewTriggerResult{
+		TriggerType: triggerType,
+		Expression:  expression,
+		TimeZone:    timeZone,
+		NextRuns:    nextRuns,
+	}
+}
+
+func PreviewTrigger(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	// receive post data
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	// deserialize data
+	requestObject := model.Request{
+		Desire: nil,
+		Data:   &PreviewTriggerRequest{},
+	}
+
+	err = json.Unmarshal(body, &requestObject)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	requestData, ok := requestObject.Data.(*PreviewTriggerRequest)
+	if !ok {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, errors.New("unexpected request data")),
+		))
+		return
+	}
+
+	_, err = govalidator.ValidateStruct(requestData)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	params["HttpBody"] = requestData
+
+	count := requestData.Count
+	if count == 0 {
+		count = defaultPreviewCount
+	}
+
+	// build the trigger the same way a job does, as if it was scheduled now
+	trigger, err := helper.NewTrigger(requestData.TriggerType, requestData.Expression, requestData.TimeZone)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, err),
+		))
+		return
+	}
+
+	fireTimes := helper.NextFireTimes(trigger, time.Now().UnixNano(), count)
+	resultObject.Data = newPreviewTriggerResult(requestData.TriggerType, requestData.Expression, requestData.TimeZone, fireTimes)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
+
+func GetJobNextRuns(w http.ResponseWriter, r *http.Request) {
+	var (
+		params       = map[string]interface{}{}
+		resultObject = model.Response{}
+	)
+
+	vars := mux.Vars(r)
+	jobID := vars["jobID"]
+
+	query := r.URL.Query()
+	count := GetIntFromQuery(query, "count", defaultPreviewCount)
+	params["JobID"] = jobID
+	params["Count"] = count
+
+	if !govalidator.IsUUIDv4(jobID) {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, errors.New("invalid job UUID")),
+		))
+		return
+	}
+
+	if count < 1 || count > helper.MaxPreviewCount {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", helper.MaxPreviewCount)),
+		))
+		return
+	}
+
+	scheduleJob, err := store.New().Get(jobID)
+	if err != nil {
+		logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+			responseError(w, &resultObject, http.StatusInternalServerError, err),
+		))
+		return
+	}
+
+	// only an enabled job has upcoming runs, starting from the next run time
+	// the scheduler already computed for it
+	fireTimes := []time.Time{}
+	if scheduleJob.Status == orm.JobStatusEnable {
+		if scheduledJob, err := global.Scheduler.GetScheduledJob(scheduleJob.JobKey); err == nil {
+			fireTimes, err = helper.PreviewJob(scheduleJob, scheduledJob.NextRunTime, count)
+			if err != nil {
+				logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+					responseError(w, &resultObject, http.StatusInternalServerError, err),
+				))
+				return
+			}
+		} else if scheduleJob.TriggerType != "once" {
+			// the job is scheduled by another replica, estimate as if it was
+			// scheduled now
+			fireTimes, err = helper.EstimateJob(scheduleJob, time.Now(), count)
+			if err != nil {
+				logger.New().Error(utils.CurrentFunctionName(), zap.Any("Params", params), zap.Error(
+					responseError(w, &resultObject, http.StatusInternalServerError, err),
+				))
+				return
+			}
+		}
+	}
+
+	resultObject.Data = newPreviewTriggerResult(scheduleJob.TriggerType, scheduleJob.Expression, scheduleJob.TimeZone, fireTimes)
+
+	logger.New().Info(utils.CurrentFunctionName(), zap.Any("Params", params))
+	result, err := json.Marshal(resultObject)
+	if nil != err {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	} else {
+		w.Header().Add("Content-Type", "application/json")
+		fmt.Fprintln(w, string(result))
+	}
+}
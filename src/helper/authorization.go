@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/secret"
+)
+
+// authentication modes of jobs
+const (
+	// the JWT or the secret referenced by a job as bearer token
+	AuthModeBearer = "bearer"
+	// an access token requested by OAuth2 client credentials
+	AuthModeOAuth2 = "oauth2"
+)
+
+// resolveJsonWebToken returns the JWT of a job, resolving its secret reference
+// on every fire so that rotated secrets take effect without updating the job
+func resolveJsonWebToken(ctx context.Context, scheduleJob *orm.ScheduleJob) (string, error) {
+	if scheduleJob.SecretRef == "" {
+		return scheduleJob.JsonWebToken, nil
+	}
+
+	return secret.Resolve(ctx, scheduleJob.SecretRef)
+}
+
+// Authorizes tells whether the requests of a job carry an Authorization header
+// set by its auth mode, which custom headers must not set as well
+func Authorizes(scheduleJob *orm.ScheduleJob) bool {
+	return scheduleJob.AuthMode == AuthModeOAuth2 || scheduleJob.JsonWebToken != "" || scheduleJob.SecretRef != ""
+}
+
+// authorize sets the bearer token of the request of a job into headers. An
+// access token rejected by the target is given as rejectedToken, so that it
+// gets refreshed.
+func authorize(ctx context.Context, scheduleJob *orm.ScheduleJob, headers map[string]string, rejectedToken string) error {
+	var (
+		token string
+		err   error
+	)
+
+	if scheduleJob.AuthMode == AuthModeOAuth2 {
+		token, err = getOAuth2Token(ctx, scheduleJob, rejectedToken)
+	} else {
+		token, err = resolveJsonWebToken(ctx, scheduleJob)
+	}
+	if err != nil {
+		return err
+	}
+
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	return nil
+}
+
+// bearerToken returns the bearer token set into headers by authorize
+func bearerToken(headers map[string]string) string {
+	return strings.TrimPrefix(headers["Authorization"], "Bearer ")
+}
@@ -0,0 +1,80 @@
+package helper
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"go.uber.org/zap"
+)
+
+// default request timeout of an attempt in second
+const DefaultTimeoutSeconds = 30
+
+var (
+	// executionContext is canceled once the grace period of a shutdown elapsed
+	executionContext, cancelExecutions = context.WithCancel(context.Background())
+	executionWaitGroup                 sync.WaitGroup
+)
+
+// newExecutionContext derives the context of an execution from the one passed by
+// go-quartz. The scheduler cancels its context as soon as it stops, so the
+// execution only inherits its values and is canceled by Shutdown instead.
+func newExecutionContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stop := context.AfterFunc(executionContext, cancel)
+
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// Shutdown waits for in-flight executions to complete and cancels the remaining
+// ones after the grace period
+func Shutdown(grace time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		executionWaitGroup.Wait()
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(grace):
+	}
+
+	logger.New().Warn("CANCELING IN-FLIGHT EXECUTIONS", zap.Duration("GracePeriod", grace))
+	cancelExecutions()
+	<-done
+}
+
+// maximum length of the response body kept in job_executions
+const maxResponseBodyLength = 4096
+
+func truncateResponseBody(body string) string {
+	if len(body) > maxResponseBodyLength {
+		// avoid leaving a partial multi-byte character behind
+		return strings.ToValidUTF8(body[:maxResponseBodyLength], "")
+	}
+	return body
+}
+
+// recordFire keeps the scheduled time of a fire delivered by a job, so that the
+// misfire policy is evaluated from it after restarts. Fires cut off by shutdown
+// are left as missed.
+func recordFire(scheduleJob *orm.ScheduleJob, execution *orm.JobExecution) {
+	if execution.Status == orm.ExecutionStatusCancelled {
+		return
+	}
+
+	err := store.New().RecordFire(execution.JobID, execution.ScheduledTime)
+	if err != nil {
+		logger.New().Error("FAILED TO UPDATE LAST FIRE TIME", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+	}
+}
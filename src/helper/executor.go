@@ -0,0 +1,323 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/httpx/client"
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/cgsl/utils"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"go.uber.org/zap"
+)
+
+func init() {
+	// retries are governed by the retry policy of each job, so that every
+	// attempt is accounted for
+	client.MaxRetry = 1
+}
+
+type fetchResult struct {
+	StatusCode int
+	Header     http.Header
+	Body       string
+}
+
+// NewJobExecution prepares the execution record of a job fired at the scheduled time
+func NewJobExecution(jobID string, scheduledTime time.Time, triggeredBy string) *orm.JobExecution {
+	return &orm.JobExecution{
+		ExecutionID:   utils.RandomUUIDString(),
+		JobID:         jobID,
+		Status:        orm.ExecutionStatusRunning,
+		ScheduledTime: scheduledTime.UnixMilli(),
+		TriggeredBy:   triggeredBy,
+	}
+}
+
+// ExecuteJob performs the webhook of a job and keeps the outcome in the execution record
+func ExecuteJob(ctx context.Context, scheduleJob *orm.ScheduleJob, execution *orm.JobExecution) error {
+	jobID := scheduleJob.JobID
+	name := scheduleJob.Name
+
+	startTime := time.Now()
+	execution.StartTime = startTime.UnixMilli()
+	if err := store.New().CreateExecution(execution); err != nil {
+		logger.New().Error("FAILED TO SAVE JOB EXECUTION", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+	}
+
+	headers := DecodeHttpHeaders(scheduleJob.HttpHeaders)
+	err := authorize(ctx, scheduleJob, headers, "")
+
+	// let receivers tell fires delivered late apart
+	if execution.TriggeredBy == orm.ExecutionTriggeredByCatchUp || execution.TriggeredBy == orm.ExecutionTriggeredByMisfire {
+		headers[BackfillHeader] = execution.TriggeredBy
+		headers[ScheduledTimeHeader] = time.UnixMilli(execution.ScheduledTime).UTC().Format(time.RFC3339)
+	}
+
+	var (
+		result   *fetchResult
+		attempts int
+	)
+	if err != nil {
+		logger.New().Error("FAILED TO AUTHORIZE", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+	} else {
+		result, attempts, err = execute(ctx, scheduleJob, execution.ExecutionID, headers)
+	}
+
+	endTime := time.Now()
+	execution.EndTime = endTime.UnixMilli()
+	execution.Latency = endTime.Sub(startTime).Milliseconds()
+	execution.Attempts = attempts
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		execution.Status = orm.ExecutionStatusCancelled
+		execution.ErrorMessage = err.Error()
+		logger.New().Warn("JOB CANCELLED", zap.String("JobID", jobID), zap.String("Name", name))
+	case err != nil:
+		execution.Status = orm.ExecutionStatusFailed
+		execution.ErrorMessage = err.Error()
+	case result.StatusCode >= 400:
+		execution.Status = orm.ExecutionStatusFailed
+		execution.HttpStatusCode = result.StatusCode
+		execution.ResponseBody = truncateResponseBody(result.Body)
+		logger.New().Info("FETCH FAILURE HTTP STATUS CODE", zap.String("JobID", jobID), zap.String("Name", name), zap.String("Response", result.Body))
+	default:
+		execution.Status = orm.ExecutionStatusSucceeded
+		execution.HttpStatusCode = result.StatusCode
+		execution.ResponseBody = truncateResponseBody(result.Body)
+		logger.New().Info("JOB ACCOMPLISHED", zap.String("JobID", jobID), zap.String("Name", name))
+	}
+
+	// keep execution history
+	if saveErr := store.New().UpdateExecution(execution); saveErr != nil {
+		logger.New().Error("FAILED TO SAVE JOB EXECUTION", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(saveErr))
+	}
+
+	return err
+}
+
+// RunJob executes a job immediately, regardless of its trigger. The execution
+// runs in background when async is set, otherwise RunJob blocks until it ends.
+func RunJob(scheduleJob orm.ScheduleJob, async bool) *orm.JobExecution {
+	execution := NewJobExecution(scheduleJob.JobID, time.Now(), orm.ExecutionTriggeredByManual)
+
+	return runJob(scheduleJob, execution, async)
+}
+
+// CatchUpJob executes a job in background on behalf of a fire missed at the
+// scheduled time. The fire is claimed and counted as missed fires delivered by
+// the misfire policy are, and nil is returned if it is not to be delivered.
+func CatchUpJob(scheduleJob orm.ScheduleJob, scheduledTime time.Time) *orm.JobExecution {
+	if !claimMissedFire(&scheduleJob, scheduledTime) {
+		return nil
+	}
+
+	execution := NewJobExecution(scheduleJob.JobID, scheduledTime, orm.ExecutionTriggeredByCatchUp)
+
+	return runJob(scheduleJob, execution, true)
+}
+
+func runJob(scheduleJob orm.ScheduleJob, execution *orm.JobExecution, async bool) *orm.JobExecution {
+	run := func() {
+		ctx, cancel := newExecutionContext(context.Background())
+		defer cancel()
+
+		ExecuteJob(ctx, &scheduleJob, execution)
+
+		// missed fires delivered are recorded as scheduled fires are, unlike
+		// manual runs
+		if execution.TriggeredBy != orm.ExecutionTriggeredByManual {
+			recordFire(&scheduleJob, execution)
+		}
+	}
+
+	// the execution is tracked before it starts, so that Shutdown waits for it
+	executionWaitGroup.Add(1)
+	if !async {
+		defer executionWaitGroup.Done()
+		run()
+		return execution
+	}
+
+	// hand out a snapshot, since the execution keeps being updated in background
+	snapshot := *execution
+	snapshot.StartTime = time.Now().UnixMilli()
+	go func() {
+		defer executionWaitGroup.Done()
+		run()
+	}()
+
+	return &snapshot
+}
+
+// execute performs the HTTP request of a job, retries it according to the
+// retry policy of the job and records every attempt
+func execute(ctx context.Context, scheduleJob *orm.ScheduleJob, executionID string, headers map[string]string) (*fetchResult, int, error) {
+	var (
+		result  *fetchResult
+		err     error
+		attempt int
+		// attempts repeated with a refreshed access token, which the retry
+		// policy does not count
+		reauthorized int
+	)
+
+	// the target URL is parsed once for every attempt
+	urlObject, err := url.Parse(scheduleJob.HttpTargetUrl)
+	if err != nil {
+		logger.New().Error("FAILED TO PARSE TARGET URL", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+		return nil, 0, err
+	}
+
+	policy := NewRetryPolicy(scheduleJob)
+	for attempt = 1; ; attempt++ {
+		// attempts beyond the limits of the target host wait for their turn
+		var (
+			release func()
+			waited  time.Duration
+		)
+		release, waited, err = hostLimiters.acquire(ctx, urlObject)
+		if err != nil {
+			logger.New().Error("FAILED TO ACQUIRE HOST LIMIT", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+			return result, attempt - 1, err
+		}
+		if waited > 0 {
+			logger.New().Info("DELAYED BY HOST LIMIT", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Duration("Delay", waited))
+		}
+
+		startTime := time.Now()
+		signRequest(scheduleJob, urlObject, headers, startTime)
+		result, err = fetchWithTimeout(ctx, scheduleJob, urlObject, headers)
+		release()
+		endTime := time.Now()
+
+		executionAttempt := orm.JobExecutionAttempt{
+			ExecutionID: executionID,
+			Attempt:     attempt,
+			JobID:       scheduleJob.JobID,
+			StartTime:   startTime.UnixMilli(),
+			EndTime:     endTime.UnixMilli(),
+			Latency:     endTime.Sub(startTime).Milliseconds(),
+		}
+
+		statusCode := 0
+		if err != nil {
+			executionAttempt.ErrorMessage = err.Error()
+		} else {
+			statusCode = result.StatusCode
+			executionAttempt.HttpStatusCode = statusCode
+		}
+
+		if saveErr := store.New().CreateExecutionAttempt(&executionAttempt); saveErr != nil {
+			logger.New().Error("FAILED TO SAVE JOB EXECUTION ATTEMPT", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(saveErr))
+		}
+
+		// an access token rejected by the target is refreshed and the attempt
+		// repeated at once, but only once
+		if statusCode == http.StatusUnauthorized && scheduleJob.AuthMode == AuthModeOAuth2 && reauthorized == 0 && ctx.Err() == nil {
+			reauthorized++
+			err = authorize(ctx, scheduleJob, headers, bearerToken(headers))
+			if err != nil {
+				break
+			}
+
+			logger.New().Info("REFRESHED OAUTH2 TOKEN", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Int("Attempt", attempt+1))
+			continue
+		}
+
+		if attempt-reauthorized >= policy.MaxAttempts || !policy.Retryable(statusCode, err) || ctx.Err() != nil {
+			break
+		}
+
+		delay := policy.Backoff(attempt - reauthorized)
+		if result != nil {
+			if retryAfter, ok := ParseRetryAfter(result.Header.Get("Retry-After"), endTime); ok {
+				// the target asks for more patience than the policy allows
+				if retryAfter > policy.MaxBackoff {
+					logger.New().Info("RETRY-AFTER EXCEEDS MAX BACKOFF", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Duration("RetryAfter", retryAfter))
+					break
+				}
+
+				if retryAfter > delay {
+					delay = retryAfter
+				}
+			}
+		}
+
+		logger.New().Info("RETRY JOB EXECUTION", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Int("Attempt", attempt+1), zap.Duration("Delay", delay))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result, attempt, ctx.Err()
+		}
+	}
+
+	return result, attempt, err
+}
+
+// fetchWithTimeout performs the HTTP request of a job to its parsed target URL
+// once within its timeout
+func fetchWithTimeout(ctx context.Context, scheduleJob *orm.ScheduleJob, urlObject *url.URL, headers map[string]string) (*fetchResult, error) {
+	if scheduleJob.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(scheduleJob.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	return fetch(ctx, scheduleJob, urlObject, headers)
+}
+
+// fetch performs the HTTP request of a job to its parsed target URL once
+func fetch(ctx context.Context, scheduleJob *orm.ScheduleJob, urlObject *url.URL, headers map[string]string) (*fetchResult, error) {
+	jobID := scheduleJob.JobID
+	name := scheduleJob.Name
+
+	httpClient, err := httpClients.get(urlObject, scheduleJob.TlsProfile)
+	if err != nil {
+		logger.New().Error("FAILED TO INIT HTTP CLIENT", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+		return nil, err
+	}
+
+	contentType := scheduleJob.ContentType
+	if contentType == "" {
+		contentType = DefaultContentType
+	}
+
+	payloadData := []byte(scheduleJob.HttpRequestBody)
+	res, err := httpClient.ExecuteMethod(
+		ctx,
+		scheduleJob.HttpMethod,
+		strings.TrimPrefix(urlObject.Path, "/"), // trim leading slash
+		client.RequestMetadata{
+			QueryValues:   urlObject.Query(),
+			Headers:       headers,
+			ContentType:   contentType,
+			ContentLength: len(payloadData),
+			ContentBody:   bytes.NewReader(payloadData),
+		},
+	)
+	if err != nil {
+		logger.New().Error("FAILED EXECUTE METHOD", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+		return nil, err
+	}
+	defer drainResponseBody(res.Body)
+
+	builder := new(strings.Builder)
+	io.Copy(builder, io.LimitReader(res.Body, maxResponseBodyLength))
+
+	return &fetchResult{
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Body:       builder.String(),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/dbx"
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/reugn/go-quartz/quartz"
+	"go.uber.org/zap"
+)
+
+// interval of purging expired fire claims
+const fireClaimPurgeInterval = time.Hour
+
+var (
+	fireLockEnabled atomic.Bool
+	fireLockHolder  string
+)
+
+// EnableFireLock makes every fire claimed by this replica as holder before
+// being delivered, so that replicas holding the same schedule deliver each
+// fire at most once
+func EnableFireLock(holder string) {
+	fireLockHolder = holder
+	fireLockEnabled.Store(true)
+}
+
+func FireLockEnabled() bool {
+	return fireLockEnabled.Load()
+}
+
+// claimFire claims the fire of a job at the scheduled time, and reports whether
+// this replica won the claim
+func claimFire(jobID string, scheduledTime time.Time) (bool, error) {
+	stmt, err := dbx.New().Prepare(`
+		INSERT IGNORE INTO job_fire_claims (JobID,FireTime,Holder,ClaimTime)
+		VALUES (?,?,?,?)
+		;
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(jobID, scheduledTime.UnixMilli(), fireLockHolder, time.Now().UnixMilli())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// PurgeFireClaims deletes fire claims older than retention every hour until
+// ctx is done
+func PurgeFireClaims(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(fireClaimPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stmt, err := dbx.New().Prepare(`
+			DELETE
+			FROM job_fire_claims
+			WHERE ClaimTime<?
+		`)
+		if err != nil {
+			logger.New().Error("FAILED TO PURGE FIRE CLAIMS", zap.Error(err))
+			continue
+		}
+
+		_, err = stmt.Exec(time.Now().Add(-retention).UnixMilli())
+		stmt.Close()
+		if err != nil {
+			logger.New().Error("FAILED TO PURGE FIRE CLAIMS", zap.Error(err))
+		}
+	}
+}
+
+// alignedIntervalTrigger fires at multiples of an interval from an anchor time
+// (epoch in nanosecond) rather than from the time it got scheduled
+type alignedIntervalTrigger struct {
+	anchor   int64
+	interval int64
+}
+
+func (trigger *alignedIntervalTrigger) NextFireTime(prev int64) (int64, error) {
+	if prev < trigger.anchor {
+		return trigger.anchor + trigger.interval, nil
+	}
+
+	return trigger.anchor + ((prev-trigger.anchor)/trigger.interval+1)*trigger.interval, nil
+}
+
+func (trigger *alignedIntervalTrigger) Description() string {
+	return "AlignedIntervalTrigger with interval: " + strconv.FormatInt(trigger.interval, 10)
+}
+
+// newAlignedTrigger derives fire times of interval and once jobs from the job
+// rather than from the time it got scheduled, so that every replica agrees on
+// them. Interval jobs fire every interval from their start time if any, or
+// from their creation time, and once jobs are anchored at their update time.
+// Cron and at fire times are aligned already.
+func newAlignedTrigger(scheduleJob *orm.ScheduleJob, trigger quartz.Trigger) (quartz.Trigger, error) {
+	switch scheduleJob.TriggerType {
+	case "interval", "once":
+		seconds, err := strconv.ParseInt(scheduleJob.Expression, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if scheduleJob.TriggerType == "interval" {
+			if seconds <= 0 {
+				return nil, errors.New("non-positive interval seconds")
+			}
+
+			interval := (time.Duration(seconds) * time.Second).Nanoseconds()
+			if scheduleJob.StartAt != 0 {
+				return &alignedIntervalTrigger{
+					anchor:   time.Unix(scheduleJob.StartAt, 0).UnixNano() - interval,
+					interval: interval,
+				}, nil
+			}
+
+			return &alignedIntervalTrigger{
+				anchor:   time.Unix(scheduleJob.CreationTime, 0).UnixNano(),
+				interval: interval,
+			}, nil
+		}
+
+		return &atTrigger{
+			fireTime: time.Unix(scheduleJob.UpdateTime+seconds, 0).UnixNano(),
+		}, nil
+	default:
+		return trigger, nil
+	}
+}
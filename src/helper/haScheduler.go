@@ -0,0 +1,208 @@
+package helper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/reugn/go-quartz/quartz"
+	"go.uber.org/zap"
+)
+
+// HAScheduler wraps the scheduler of a replica, so that only jobs of the
+// elected leader are actually scheduled. Followers keep serving the REST API,
+// and the leader picks up their changes by reconciling with schedule_jobs.
+// Without election every replica schedules every job, as replicas claiming
+// fires do.
+type HAScheduler struct {
+	quartz.Scheduler
+	election *LeaderElection
+	restore  func(quartz.Scheduler) error
+
+	mtx sync.Mutex
+
+	// job IDs found unscheduled and job keys found outdated in the last
+	// reconciliation, which are only acted on when seen twice in a row to let
+	// in-progress API calls and once jobs settle
+	missing map[string]bool
+	orphans map[int]bool
+}
+
+func NewHAScheduler(scheduler quartz.Scheduler, election *LeaderElection, restore func(quartz.Scheduler) error) *HAScheduler {
+	haScheduler := &HAScheduler{
+		Scheduler: scheduler,
+		election:  election,
+		restore:   restore,
+		missing:   map[string]bool{},
+		orphans:   map[int]bool{},
+	}
+
+	if election != nil {
+		election.OnElected = haScheduler.promote
+		election.OnDemoted = haScheduler.demote
+	}
+
+	return haScheduler
+}
+
+func (haScheduler *HAScheduler) isLeader() bool {
+	return haScheduler.election == nil || haScheduler.election.IsLeader()
+}
+
+// ScheduleJob schedules a job on the leader only, followers leave it to the
+// reconciliation of the leader
+func (haScheduler *HAScheduler) ScheduleJob(ctx context.Context, job quartz.Job, trigger quartz.Trigger) error {
+	haScheduler.mtx.Lock()
+	defer haScheduler.mtx.Unlock()
+
+	if !haScheduler.isLeader() {
+		releaseJob(job.Key())
+		return nil
+	}
+
+	return haScheduler.Scheduler.ScheduleJob(ctx, job, trigger)
+}
+
+func (haScheduler *HAScheduler) promote() {
+	ClearJobs(haScheduler.Scheduler)
+	if err := haScheduler.restore(haScheduler); err != nil {
+		logger.New().Error("FAILED TO RESTORE SCHEDULE JOB(S)", zap.Error(err))
+	}
+}
+
+func (haScheduler *HAScheduler) demote() {
+	haScheduler.mtx.Lock()
+	defer haScheduler.mtx.Unlock()
+
+	ClearJobs(haScheduler.Scheduler)
+	haScheduler.missing = map[string]bool{}
+	haScheduler.orphans = map[int]bool{}
+}
+
+// Reconcile keeps the jobs of the leader in line with schedule_jobs every
+// interval until ctx is done
+func (haScheduler *HAScheduler) Reconcile(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !haScheduler.isLeader() {
+			continue
+		}
+
+		if err := haScheduler.reconcile(); err != nil {
+			logger.New().Error("FAILED TO RECONCILE SCHEDULE JOB(S)", zap.Error(err))
+		}
+	}
+}
+
+func (haScheduler *HAScheduler) reconcile() error {
+	scheduleJobs, _, err := store.New().List(&store.JobQuery{
+		Conditions: []store.JobCondition{
+			{Column: "Status", Operator: store.OperatorIn, Values: []interface{}{orm.JobStatusEnable}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	haScheduler.mtx.Lock()
+	previousMissing := haScheduler.missing
+	previousOrphans := haScheduler.orphans
+	haScheduler.mtx.Unlock()
+
+	// index scheduled jobs by job ID, since job keys are local to a replica
+	scheduledVersions := map[string]map[int]int{}
+	for _, jobKey := range haScheduler.GetJobKeys() {
+		scheduledJob, err := haScheduler.GetScheduledJob(jobKey)
+		if err != nil {
+			continue
+		}
+
+		jobID, version, ok := parseJobDescription(scheduledJob.Job.Description())
+		if !ok {
+			continue
+		}
+
+		if scheduledVersions[jobID] == nil {
+			scheduledVersions[jobID] = map[int]int{}
+		}
+		scheduledVersions[jobID][jobKey] = version
+	}
+
+	upToDateKeys := map[int]bool{}
+	missing := map[string]bool{}
+
+	for _, scheduleJob := range scheduleJobs {
+		upToDate := false
+		for jobKey, version := range scheduledVersions[scheduleJob.JobID] {
+			if version == scheduleJob.Version && !upToDate {
+				upToDateKeys[jobKey] = true
+				upToDate = true
+			}
+		}
+
+		if upToDate {
+			continue
+		}
+
+		if !previousMissing[scheduleJob.JobID] {
+			missing[scheduleJob.JobID] = true
+			continue
+		}
+
+		job, err := NewJob(haScheduler, scheduleJob)
+		if err != nil {
+			logger.New().Error("FAILED TO RECONCILE SCHEDULE JOB", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+			continue
+		}
+
+		// record the key on the leader, unless the job has been modified
+		// meanwhile, which is left to the next round
+		if haScheduler.election != nil {
+			updated, err := store.New().UpdateJobKey(&scheduleJob, job.Key())
+			if err != nil || !updated {
+				UnscheduleJob(haScheduler, job.Key())
+				continue
+			}
+		}
+
+		upToDateKeys[job.Key()] = true
+		logger.New().Info("SCHEDULE JOB WAS RECONCILED", zap.String("JobID", scheduleJob.JobID), zap.Int("JobKey", job.Key()), zap.String("Name", scheduleJob.Name))
+	}
+
+	// jobs of outdated versions, disabled or deleted jobs
+	orphans := map[int]bool{}
+	for _, versions := range scheduledVersions {
+		for jobKey := range versions {
+			if upToDateKeys[jobKey] {
+				continue
+			}
+
+			if !previousOrphans[jobKey] {
+				orphans[jobKey] = true
+				continue
+			}
+
+			UnscheduleJob(haScheduler, jobKey)
+			logger.New().Info("ORPHAN JOB WAS UNSCHEDULED", zap.Int("JobKey", jobKey))
+		}
+	}
+
+	haScheduler.mtx.Lock()
+	defer haScheduler.mtx.Unlock()
+
+	haScheduler.missing = missing
+	haScheduler.orphans = orphans
+
+	return nil
+}
@@ -0,0 +1,345 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"go.uber.org/zap"
+)
+
+// DefaultHostLimitMaxWait is the longest a request waits for the limits of its
+// target host by default, failing the execution afterwards
+const DefaultHostLimitMaxWait = 5 * time.Minute
+
+// DefaultHostLimitHost stands for every host without a limit of its own, whose
+// stored limit takes the place of the default one
+const DefaultHostLimitHost = "*"
+
+var ErrHostLimitWait = errors.New("waited too long for the limits of target host")
+
+// hostLimiter enforces the limit of a host by a token bucket refilled at
+// RequestsPerSecond up to Burst, along with a count of requests in flight
+type hostLimiter struct {
+	limit    orm.HostLimit
+	tokens   float64
+	last     time.Time
+	inFlight int
+	// requests waiting for their turn, first come first served
+	waiters []*hostLimitWaiter
+	// closed and replaced whenever a request leaves, the queue moves or the
+	// limit changes, waking up requests waiting for their turn
+	changed chan struct{}
+}
+
+// hostLimitWaiter is a request waiting for its turn, which is never of zero
+// size, so that waiters are told apart by address
+type hostLimitWaiter struct {
+	startTime time.Time
+}
+
+func newHostLimiter(limit orm.HostLimit, now time.Time) *hostLimiter {
+	return &hostLimiter{
+		limit:   limit,
+		tokens:  float64(limit.Burst),
+		last:    now,
+		changed: make(chan struct{}),
+	}
+}
+
+// unlimited tells whether a limit lets every request through at once
+func unlimited(limit orm.HostLimit) bool {
+	return limit.RequestsPerSecond <= 0 && limit.MaxInFlight <= 0
+}
+
+func (limiter *hostLimiter) notify() {
+	close(limiter.changed)
+	limiter.changed = make(chan struct{})
+}
+
+// dequeue removes a waiter from the queue, and lets the next one in line try
+// its turn
+func (limiter *hostLimiter) dequeue(waiter *hostLimitWaiter) {
+	for i := range limiter.waiters {
+		if limiter.waiters[i] == waiter {
+			limiter.waiters = append(limiter.waiters[:i], limiter.waiters[i+1:]...)
+			break
+		}
+	}
+
+	limiter.notify()
+}
+
+// reserve takes a token and an in-flight slot if both are available, and
+// otherwise returns how long to wait for a token, or zero to wait for a
+// request to leave
+func (limiter *hostLimiter) reserve(now time.Time) (bool, time.Duration) {
+	if limiter.limit.MaxInFlight > 0 && limiter.inFlight >= limiter.limit.MaxInFlight {
+		return false, 0
+	}
+
+	if limiter.limit.RequestsPerSecond > 0 {
+		limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.limit.RequestsPerSecond
+		if burst := math.Max(float64(limiter.limit.Burst), 1); limiter.tokens > burst {
+			limiter.tokens = burst
+		}
+		limiter.last = now
+
+		if limiter.tokens < 1 {
+			return false, time.Duration((1 - limiter.tokens) / limiter.limit.RequestsPerSecond * float64(time.Second))
+		}
+		limiter.tokens--
+	}
+
+	limiter.inFlight++
+	return true, 0
+}
+
+// hostLimiterRegistry keeps the limiters of target hosts, limited by the
+// limit of the host if any, or else by the default one
+type hostLimiterRegistry struct {
+	mtx          sync.Mutex
+	defaultLimit orm.HostLimit
+	maxWait      time.Duration
+	limits       map[string]orm.HostLimit
+	limiters     map[string]*hostLimiter
+}
+
+var hostLimiters = &hostLimiterRegistry{
+	maxWait:  DefaultHostLimitMaxWait,
+	limits:   map[string]orm.HostLimit{},
+	limiters: map[string]*hostLimiter{},
+}
+
+// InitHostLimits sets the limit of hosts without a limit of their own, and
+// how long requests wait for the limits of their target host at most
+func InitHostLimits(defaultLimit orm.HostLimit, maxWait time.Duration) {
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	hostLimiters.defaultLimit = defaultLimit
+	hostLimiters.maxWait = maxWait
+	hostLimiters.apply()
+}
+
+// DefaultHostLimit returns the limit of hosts without a limit of their own
+func DefaultHostLimit() orm.HostLimit {
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	return hostLimiters.limit(DefaultHostLimitHost)
+}
+
+// LoadHostLimits replaces the limits of hosts with the stored ones
+func LoadHostLimits() error {
+	hostLimits, err := store.New().ListHostLimits()
+	if err != nil {
+		return err
+	}
+
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	hostLimiters.limits = map[string]orm.HostLimit{}
+	for _, hostLimit := range hostLimits {
+		hostLimiters.limits[hostLimit.Host] = hostLimit
+	}
+	hostLimiters.apply()
+
+	return nil
+}
+
+// RefreshHostLimits loads the stored limits of hosts periodically, so that
+// limits edited through other replicas take effect, until ctx is done
+func RefreshHostLimits(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := LoadHostLimits(); err != nil {
+				logger.New().Error("FAILED TO LOAD HOST LIMITS", zap.Error(err))
+			}
+		}
+	}
+}
+
+// SetHostLimit puts the limit of a host into effect at once
+func SetHostLimit(hostLimit orm.HostLimit) {
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	hostLimiters.limits[hostLimit.Host] = hostLimit
+	hostLimiters.apply()
+}
+
+// RemoveHostLimit brings a host back to the default limit, or the default
+// limit back to the one it was initialized with
+func RemoveHostLimit(host string) {
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	delete(hostLimiters.limits, host)
+	hostLimiters.apply()
+}
+
+// HostLimitUsage returns the number of requests to a host in flight and the
+// number of them waiting for their turn
+func HostLimitUsage(host string) (int, int) {
+	hostLimiters.mtx.Lock()
+	defer hostLimiters.mtx.Unlock()
+
+	limiter, ok := hostLimiters.limiters[host]
+	if !ok {
+		return 0, 0
+	}
+
+	return limiter.inFlight, len(limiter.waiters)
+}
+
+// NormalizeHost returns the host limits are keyed by, which is in lower case
+// and free of the default port of the scheme
+func NormalizeHost(scheme string, host string) string {
+	host = strings.ToLower(host)
+	if hostname, port, err := net.SplitHostPort(host); err == nil {
+		if strings.EqualFold(scheme, "http") && port == "80" || strings.EqualFold(scheme, "https") && port == "443" {
+			return hostname
+		}
+	}
+
+	return host
+}
+
+// limit returns the limit in effect for a host. The lock must be held.
+func (registry *hostLimiterRegistry) limit(host string) orm.HostLimit {
+	if limit, ok := registry.limits[host]; ok {
+		return limit
+	}
+
+	limit, ok := registry.limits[DefaultHostLimitHost]
+	if !ok {
+		limit = registry.defaultLimit
+	}
+	limit.Host = host
+	return limit
+}
+
+// apply updates the limiters to the limits in effect, dropping idle limiters
+// of hosts no longer limited. Requests in flight keep counting against the
+// updated limits. The lock must be held.
+func (registry *hostLimiterRegistry) apply() {
+	for host, limiter := range registry.limiters {
+		limit := registry.limit(host)
+		if unlimited(limit) && limiter.inFlight == 0 && len(limiter.waiters) == 0 {
+			delete(registry.limiters, host)
+			continue
+		}
+
+		if limit != limiter.limit {
+			limiter.limit = limit
+			if limiter.tokens > float64(limit.Burst) {
+				limiter.tokens = float64(limit.Burst)
+			}
+			limiter.notify()
+		}
+	}
+}
+
+// acquire waits until a request to the target URL of a job is allowed by the
+// limits of its host, and returns the function to call once the request is
+// done along with how long it waited
+func (registry *hostLimiterRegistry) acquire(ctx context.Context, urlObject *url.URL) (func(), time.Duration, error) {
+	host := NormalizeHost(urlObject.Scheme, urlObject.Host)
+
+	startTime := time.Now()
+
+	registry.mtx.Lock()
+	limiter, ok := registry.limiters[host]
+	if !ok {
+		limit := registry.limit(host)
+		if unlimited(limit) {
+			registry.mtx.Unlock()
+			return func() {}, 0, nil
+		}
+
+		limiter = newHostLimiter(limit, startTime)
+		registry.limiters[host] = limiter
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, registry.maxWait)
+	defer cancel()
+
+	// requests waiting take their turns in the order they came in
+	waiter := &hostLimitWaiter{startTime: startTime}
+	queued := false
+	for {
+		delay := time.Duration(0)
+		if len(limiter.waiters) == 0 || limiter.waiters[0] == waiter {
+			var ok bool
+			ok, delay = limiter.reserve(time.Now())
+			if ok {
+				if queued {
+					limiter.dequeue(waiter)
+				}
+				registry.mtx.Unlock()
+				break
+			}
+		}
+
+		if !queued {
+			limiter.waiters = append(limiter.waiters, waiter)
+			queued = true
+		}
+		changed := limiter.changed
+		registry.mtx.Unlock()
+
+		var timer *time.Timer
+		var expired <-chan time.Time
+		if delay > 0 {
+			timer = time.NewTimer(delay)
+			expired = timer.C
+		}
+
+		select {
+		case <-expired:
+		case <-changed:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		registry.mtx.Lock()
+		if ctx.Err() != nil {
+			limiter.dequeue(waiter)
+			registry.mtx.Unlock()
+
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && time.Since(startTime) >= registry.maxWait {
+				return nil, 0, fmt.Errorf("%w %s", ErrHostLimitWait, host)
+			}
+			return nil, 0, ctx.Err()
+		}
+	}
+
+	release := func() {
+		registry.mtx.Lock()
+		defer registry.mtx.Unlock()
+
+		limiter.inFlight--
+		limiter.notify()
+	}
+
+	return release, time.Since(startTime), nil
+}
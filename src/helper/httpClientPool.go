@@ -0,0 +1,140 @@
+package helper
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/httpx/client"
+)
+
+const (
+	DefaultMaxConnsPerHost     = 64
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+	// max length of response bodies being drained, beyond which the
+	// connection is closed rather than read on
+	maxDrainedBodyLength = 64 << 10
+)
+
+// httpClientKey identifies the clients shared by jobs targeting the same host
+// the same way
+type httpClientKey struct {
+	secure     bool
+	host       string
+	tlsProfile string
+}
+
+// httpClientPool keeps a client per target host, whose connections are kept
+// alive across fires by a transport per TLS profile
+type httpClientPool struct {
+	mtx                 sync.Mutex
+	maxConnsPerHost     int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	clients             map[httpClientKey]*client.Client
+	transports          map[string]http.RoundTripper
+}
+
+var httpClients = newHttpClientPool(DefaultMaxConnsPerHost, DefaultMaxIdleConnsPerHost, DefaultIdleConnTimeout)
+
+func newHttpClientPool(maxConnsPerHost int, maxIdleConnsPerHost int, idleConnTimeout time.Duration) *httpClientPool {
+	return &httpClientPool{
+		maxConnsPerHost:     maxConnsPerHost,
+		maxIdleConnsPerHost: maxIdleConnsPerHost,
+		idleConnTimeout:     idleConnTimeout,
+		clients:             map[httpClientKey]*client.Client{},
+		transports:          map[string]http.RoundTripper{},
+	}
+}
+
+// InitHttpClientPool limits the connections of the clients performing
+// requests of jobs, which has to be done before jobs fire. Zero
+// maxConnsPerHost means no limit.
+func InitHttpClientPool(maxConnsPerHost int, maxIdleConnsPerHost int, idleConnTimeout time.Duration) {
+	httpClients = newHttpClientPool(maxConnsPerHost, maxIdleConnsPerHost, idleConnTimeout)
+}
+
+// get returns the client of the target host of a URL, creating it on first
+// use
+func (pool *httpClientPool) get(urlObject *url.URL, tlsProfile string) (*client.Client, error) {
+	// enable secure while HTTP scheme is "https"
+	key := httpClientKey{
+		secure:     strings.EqualFold(urlObject.Scheme, "https"),
+		host:       urlObject.Host,
+		tlsProfile: tlsProfile,
+	}
+
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+
+	if httpClient, ok := pool.clients[key]; ok {
+		return httpClient, nil
+	}
+
+	transport, err := pool.transport(tlsProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := client.New(key.host, &client.Options{
+		Secure:    key.secure,
+		Transport: transport,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool.clients[key] = httpClient
+	return httpClient, nil
+}
+
+// transport returns the transport of a TLS profile, or the default one for no
+// profile. The pool lock must be held.
+func (pool *httpClientPool) transport(tlsProfile string) (http.RoundTripper, error) {
+	if transport, ok := pool.transports[tlsProfile]; ok {
+		return transport, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = pool.maxConnsPerHost
+	transport.MaxIdleConnsPerHost = pool.maxIdleConnsPerHost
+	transport.IdleConnTimeout = pool.idleConnTimeout
+
+	if tlsProfile != "" {
+		tlsConfig, err := tlsProfileConfig(tlsProfile)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	pool.transports[tlsProfile] = cookielessTransport{transport}
+	return pool.transports[tlsProfile], nil
+}
+
+// cookielessTransport drops cookies set by targets, which clients would
+// otherwise replay to every job sharing the host
+type cookielessTransport struct {
+	transport http.RoundTripper
+}
+
+func (transport cookielessTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := transport.transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Header.Del("Set-Cookie")
+	return response, nil
+}
+
+// drainResponseBody reads what is left of a response body and closes it, so
+// that its connection is reused
+func drainResponseBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainedBodyLength))
+	body.Close()
+}
@@ -0,0 +1,250 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/reugn/go-quartz/quartz"
+)
+
+// TestFetchReusesConnections fetches responses of several statuses and sizes
+// over and over, checking that their bodies are drained and closed so that
+// the connection of the client is reused
+func TestFetchReusesConnections(t *testing.T) {
+	previousClients := httpClients
+	t.Cleanup(func() {
+		httpClients = previousClients
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		length, _ := strconv.Atoi(r.URL.Query().Get("length"))
+		w.WriteHeader(status)
+		w.Write([]byte(strings.Repeat("x", length)))
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name   string
+		status int
+		length int
+	}{
+		{name: "ok", status: http.StatusOK, length: 16},
+		{name: "no content", status: http.StatusNoContent},
+		{name: "body beyond kept length", status: http.StatusOK, length: 2 * maxResponseBodyLength},
+		{name: "not found", status: http.StatusNotFound, length: 16},
+		{name: "server error beyond kept length", status: http.StatusInternalServerError, length: 2 * maxResponseBodyLength},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			httpClients = newHttpClientPool(DefaultMaxConnsPerHost, DefaultMaxIdleConnsPerHost, DefaultIdleConnTimeout)
+
+			urlObject, err := url.Parse(server.URL + "/hooks/a?status=" + strconv.Itoa(test.status) + "&length=" + strconv.Itoa(test.length))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scheduleJob := &orm.ScheduleJob{
+				JobID:          "job",
+				Name:           t.Name(),
+				HttpMethod:     http.MethodPost,
+				HttpTargetUrl:  urlObject.String(),
+				ContentType:    DefaultContentType,
+				TimeoutSeconds: DefaultTimeoutSeconds,
+			}
+
+			for i := 0; i < 5; i++ {
+				var connInfo httptrace.GotConnInfo
+				ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+					GotConn: func(info httptrace.GotConnInfo) {
+						connInfo = info
+					},
+				})
+
+				result, err := fetchWithTimeout(ctx, scheduleJob, urlObject, map[string]string{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.StatusCode != test.status {
+					t.Fatalf("got status %d, want %d", result.StatusCode, test.status)
+				}
+				if length := min(test.length, maxResponseBodyLength); len(result.Body) != length {
+					t.Fatalf("got body of %d bytes, want %d", len(result.Body), length)
+				}
+
+				// the first fetch dials the connection the others reuse
+				if i > 0 && !connInfo.Reused {
+					t.Errorf("fetch %d got a new connection, want the one of the first fetch", i)
+				}
+			}
+		})
+	}
+}
+
+// number of interval jobs firing in the benchmark
+const benchmarkJobs = 3000
+
+// BenchmarkIntervalJobs schedules thousands of interval jobs firing against a
+// local server, reporting the throughput and the connections the server
+// accepted
+func BenchmarkIntervalJobs(b *testing.B) {
+	b.Run("http", func(b *testing.B) {
+		benchmarkIntervalJobs(b, false)
+	})
+
+	b.Run("https", func(b *testing.B) {
+		benchmarkIntervalJobs(b, true)
+	})
+}
+
+func benchmarkIntervalJobs(b *testing.B, secure bool) {
+	// the benchmark replaces the client pool, the TLS profiles and the store
+	// used by jobs
+	previousClients := httpClients
+	previousTlsProfileConfigs := tlsProfileConfigs
+	previousStore := store.New()
+	b.Cleanup(func() {
+		httpClients = previousClients
+		tlsProfileConfigs = previousTlsProfileConfigs
+		store.Init(previousStore)
+	})
+
+	var (
+		fires   int64
+		conns   int64
+		started = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	// responses outgrow what is kept of them, so that they have to be drained
+	responseBody := strings.Repeat("x", 2*maxResponseBodyLength)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responseBody))
+
+		// the first fire starts the timer, which stops b.N fires later
+		switch atomic.AddInt64(&fires, 1) {
+		case 1:
+			close(started)
+		case int64(b.N) + 1:
+			close(done)
+		}
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+
+	tlsProfile := ""
+	if secure {
+		server.StartTLS()
+		tlsProfile = "benchmark"
+		loadBenchmarkTlsProfile(b, server, tlsProfile)
+	} else {
+		server.Start()
+	}
+	b.Cleanup(server.Close)
+
+	httpClients = newHttpClientPool(DefaultMaxConnsPerHost, DefaultMaxIdleConnsPerHost, DefaultIdleConnTimeout)
+	store.Init(store.NewMemoryStore())
+
+	scheduler := quartz.NewStdScheduler()
+	scheduler.Start(context.Background())
+
+	// jobs are withdrawn and their in-flight fires complete before the globals
+	// are restored
+	b.Cleanup(func() {
+		ClearJobs(scheduler)
+		scheduler.Stop()
+		executionWaitGroup.Wait()
+	})
+
+	for i := 0; i < benchmarkJobs; i++ {
+		scheduleJob := orm.ScheduleJob{
+			JobID:           fmt.Sprintf("job-%d", i),
+			Status:          orm.JobStatusEnable,
+			Name:            fmt.Sprintf("job-%d", i),
+			TriggerType:     "interval",
+			Expression:      "1",
+			TimeZone:        DefaultTimeZone,
+			HttpMethod:      http.MethodPost,
+			HttpTargetUrl:   fmt.Sprintf("%s/jobs/%d", server.URL, i),
+			HttpRequestBody: `{"ping":true}`,
+			ContentType:     DefaultContentType,
+			TlsProfile:      tlsProfile,
+			TimeoutSeconds:  DefaultTimeoutSeconds,
+			Version:         1,
+		}
+		if err := store.New().Create(&scheduleJob); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := NewJob(scheduler, scheduleJob); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// every job fires once a second at least
+	timeout := time.Duration(b.N/benchmarkJobs+30) * time.Second
+	deadline := time.After(timeout)
+
+	select {
+	case <-started:
+	case <-deadline:
+		b.Fatalf("no fires in %v", timeout)
+	}
+
+	b.ResetTimer()
+	select {
+	case <-done:
+	case <-deadline:
+		b.Fatalf("got %d fires in %v, want %d", atomic.LoadInt64(&fires)-1, timeout, b.N)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "fires/s")
+	b.ReportMetric(float64(atomic.LoadInt64(&conns)), "conns")
+}
+
+// loadBenchmarkTlsProfile loads a TLS profile trusting the certificate of a
+// server
+func loadBenchmarkTlsProfile(b *testing.B, server *httptest.Server, name string) {
+	directory := b.TempDir()
+
+	caFile := filepath.Join(directory, "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certificate, 0600); err != nil {
+		b.Fatal(err)
+	}
+
+	profiles, err := json.Marshal(map[string]*TlsProfile{name: {CAFile: caFile}})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	profilesFile := filepath.Join(directory, "profiles.json")
+	if err := os.WriteFile(profilesFile, profiles, 0600); err != nil {
+		b.Fatal(err)
+	}
+
+	if err := LoadTlsProfiles(profilesFile); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -0,0 +1,119 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/cloud01-wu/scheduler/signature"
+	"golang.org/x/net/http/httpguts"
+)
+
+// default content type of a job request
+const DefaultContentType = "application/octet-stream"
+
+// headers flagging fires delivered later than scheduled, carrying the trigger
+// of the execution (catchup or misfire) and the scheduled time in RFC 3339
+const (
+	BackfillHeader      = "X-Scheduler-Backfill"
+	ScheduledTimeHeader = "X-Scheduler-Scheduled-Time"
+)
+
+// headers which are either hop-by-hop or controlled by the scheduler itself
+var reservedHttpHeaders = map[string]bool{
+	"Connection":              true,
+	"Content-Length":          true,
+	"Content-Type":            true,
+	"Host":                    true,
+	"Keep-Alive":              true,
+	"Proxy-Authenticate":      true,
+	"Proxy-Authorization":     true,
+	"Proxy-Connection":        true,
+	"Te":                      true,
+	"Trailer":                 true,
+	"Transfer-Encoding":       true,
+	"Upgrade":                 true,
+	BackfillHeader:            true,
+	ScheduledTimeHeader:       true,
+	signature.TimestampHeader: true,
+	signature.SignatureHeader: true,
+}
+
+// ValidateHttpHeaders checks custom headers of a job, whose names must differ
+// in more than case
+func ValidateHttpHeaders(headers map[string]string) error {
+	names := map[string]bool{}
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+
+		canonicalName := http.CanonicalHeaderKey(name)
+		if reservedHttpHeaders[canonicalName] {
+			return fmt.Errorf("header %q is not allowed", name)
+		}
+
+		if names[canonicalName] {
+			return fmt.Errorf("header %q is given more than once", canonicalName)
+		}
+		names[canonicalName] = true
+
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value of header %q", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateContentType checks the media type of a job request
+func ValidateContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("media type %q lacks a subtype", mediaType)
+	}
+
+	return nil
+}
+
+// canonicalHttpHeaders returns headers keyed by their canonical names, so that
+// they are told apart from the ones set by the scheduler
+func canonicalHttpHeaders(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		result[http.CanonicalHeaderKey(name)] = value
+	}
+
+	return result
+}
+
+func EncodeHttpHeaders(headers map[string]string) (string, error) {
+	if len(headers) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(canonicalHttpHeaders(headers))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func DecodeHttpHeaders(headers string) map[string]string {
+	result := map[string]string{}
+	if headers == "" {
+		return result
+	}
+
+	// rows are always written by EncodeHttpHeaders, yet older rows may hold
+	// names in any case
+	json.Unmarshal([]byte(headers), &result)
+	return canonicalHttpHeaders(result)
+}
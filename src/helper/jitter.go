@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+// modes of delaying fires within the jitter window of a job
+const (
+	// every fire is delayed by its own pseudo-random amount
+	JitterModeRandom = "random"
+	// every fire is delayed by the same amount, which is stable per job
+	JitterModeSpread = "spread"
+)
+
+// JitterOffset returns the delay of the fire of a job scheduled at fireTime,
+// within the jitter window of the job at millisecond precision. The delay is
+// derived from JobID and, unless spread, the fire time, so that previews and
+// replicas agree on it.
+func JitterOffset(scheduleJob *orm.ScheduleJob, fireTime time.Time) time.Duration {
+	if scheduleJob.JitterSeconds <= 0 {
+		return 0
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(scheduleJob.JobID))
+	if scheduleJob.JitterMode != JitterModeSpread {
+		binary.Write(hash, binary.BigEndian, fireTime.UnixNano())
+	}
+	sum := binary.BigEndian.Uint64(hash.Sum(nil))
+
+	window := uint64(time.Duration(scheduleJob.JitterSeconds) * time.Second / time.Millisecond)
+	return time.Duration(sum%window) * time.Millisecond
+}
+
+// jitterFireTimes delays scheduled fire times of a job by their jitter offsets,
+// keeping them in chronological order
+func jitterFireTimes(scheduleJob *orm.ScheduleJob, fireTimes []time.Time) []time.Time {
+	if scheduleJob.JitterSeconds <= 0 {
+		return fireTimes
+	}
+
+	for i, fireTime := range fireTimes {
+		fireTimes[i] = fireTime.Add(JitterOffset(scheduleJob, fireTime))
+	}
+
+	// windows wider than the gaps between fires let them overtake each other
+	sort.Slice(fireTimes, func(i, j int) bool {
+		return fireTimes[i].Before(fireTimes[j])
+	})
+
+	return fireTimes
+}
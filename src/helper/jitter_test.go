@@ -0,0 +1,275 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"github.com/reugn/go-quartz/quartz"
+)
+
+// jitterTarget records the times requests of jobs arrive at
+type jitterTarget struct {
+	mtx   sync.Mutex
+	hits  []time.Time
+	added chan struct{}
+}
+
+func newJitterTarget(t *testing.T) (*jitterTarget, *httptest.Server) {
+	target := &jitterTarget{added: make(chan struct{}, 16)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target.mtx.Lock()
+		target.hits = append(target.hits, time.Now())
+		target.mtx.Unlock()
+		target.added <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+
+	return target, server
+}
+
+func (target *jitterTarget) count() int {
+	target.mtx.Lock()
+	defer target.mtx.Unlock()
+
+	return len(target.hits)
+}
+
+func (target *jitterTarget) first() time.Time {
+	target.mtx.Lock()
+	defer target.mtx.Unlock()
+
+	return target.hits[0]
+}
+
+// wait waits for count requests, failing the test after timeout
+func (target *jitterTarget) wait(t *testing.T, count int, timeout time.Duration) {
+	deadline := time.After(timeout)
+	for target.count() < count {
+		select {
+		case <-target.added:
+		case <-deadline:
+			t.Fatalf("got %d requests, want %d", target.count(), count)
+		}
+	}
+}
+
+// startJitterScheduler runs jobs on a scheduler backed by an in-memory store
+// for the rest of the test
+func startJitterScheduler(t *testing.T) quartz.Scheduler {
+	previous := store.New()
+	store.Init(store.NewMemoryStore())
+
+	scheduler := quartz.NewStdScheduler()
+	scheduler.Start(context.Background())
+
+	// jobs are withdrawn and their in-flight fires complete before the store
+	// is restored
+	t.Cleanup(func() {
+		ClearJobs(scheduler)
+		scheduler.Stop()
+		executionWaitGroup.Wait()
+		store.Init(previous)
+	})
+
+	return scheduler
+}
+
+func newJitterJob(t *testing.T, jobID string, targetUrl string) orm.ScheduleJob {
+	return orm.ScheduleJob{
+		JobID:          jobID,
+		Status:         orm.JobStatusEnable,
+		Name:           t.Name(),
+		TimeZone:       DefaultTimeZone,
+		HttpMethod:     http.MethodPost,
+		HttpTargetUrl:  targetUrl,
+		ContentType:    DefaultContentType,
+		TimeoutSeconds: DefaultTimeoutSeconds,
+		Version:        1,
+	}
+}
+
+// scheduleJitterJob stores and schedules a job, returning its key
+func scheduleJitterJob(t *testing.T, scheduler quartz.Scheduler, scheduleJob orm.ScheduleJob) int {
+	if err := store.New().Create(&scheduleJob); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := NewJob(scheduler, scheduleJob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return job.Key()
+}
+
+// spreadJobID returns a job ID whose spread offset within a jitter window is
+// at least minOffset
+func spreadJobID(jitterSeconds int, minOffset time.Duration) (string, time.Duration) {
+	for i := 0; ; i++ {
+		scheduleJob := &orm.ScheduleJob{
+			JobID:         fmt.Sprintf("jitter-%d", i),
+			JitterSeconds: jitterSeconds,
+			JitterMode:    JitterModeSpread,
+		}
+		if offset := JitterOffset(scheduleJob, time.Time{}); offset >= minOffset {
+			return scheduleJob.JobID, offset
+		}
+	}
+}
+
+// waitJobReleased waits for a job to drop its cancellation, failing the test
+// after timeout
+func waitJobReleased(t *testing.T, jobKey int, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for {
+		jobCancels.mtx.Lock()
+		_, ok := jobCancels.cancels[jobKey]
+		jobCancels.mtx.Unlock()
+
+		if !ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job is not released")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func assertJobStatus(t *testing.T, jobID string, status int) {
+	scheduleJob, err := store.New().Get(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scheduleJob.Status != status {
+		t.Errorf("got status %d, want %d", scheduleJob.Status, status)
+	}
+}
+
+func TestJitterOffset(t *testing.T) {
+	fireTime := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		jitterSeconds int
+		jitterMode    string
+	}{
+		{name: "none", jitterSeconds: 0, jitterMode: JitterModeRandom},
+		{name: "random", jitterSeconds: 60, jitterMode: JitterModeRandom},
+		{name: "spread", jitterSeconds: 60, jitterMode: JitterModeSpread},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scheduleJob := &orm.ScheduleJob{
+				JobID:         "6f1c7a43-3c2e-4b8e-9a57-0d4c1f2b8e61",
+				JitterSeconds: test.jitterSeconds,
+				JitterMode:    test.jitterMode,
+			}
+
+			offsets := map[time.Duration]bool{}
+			for i := 0; i < 10; i++ {
+				offset := JitterOffset(scheduleJob, fireTime.Add(time.Duration(i)*time.Minute))
+				if offset < 0 || offset > 0 && offset >= time.Duration(test.jitterSeconds)*time.Second {
+					t.Fatalf("offset %v out of window", offset)
+				}
+				if offset != JitterOffset(scheduleJob, fireTime.Add(time.Duration(i)*time.Minute)) {
+					t.Fatal("offset is not stable")
+				}
+				offsets[offset] = true
+			}
+
+			switch {
+			case test.jitterSeconds == 0 && !offsets[0]:
+				t.Error("jobs without jitter are delayed")
+			case test.jitterMode == JitterModeSpread && len(offsets) != 1:
+				t.Errorf("got %d offsets in spread mode, want 1", len(offsets))
+			case test.jitterMode == JitterModeRandom && test.jitterSeconds > 0 && len(offsets) < 2:
+				t.Error("fires in random mode share an offset")
+			}
+		})
+	}
+}
+
+func TestJitterOnceJob(t *testing.T) {
+	scheduler := startJitterScheduler(t)
+	target, server := newJitterTarget(t)
+
+	jobID, offset := spreadJobID(1, 200*time.Millisecond)
+	scheduleJob := newJitterJob(t, jobID, server.URL)
+	scheduleJob.TriggerType = "once"
+	scheduleJob.Expression = "1"
+	scheduleJob.JitterSeconds = 1
+	scheduleJob.JitterMode = JitterModeSpread
+
+	startTime := time.Now()
+	jobKey := scheduleJitterJob(t, scheduler, scheduleJob)
+
+	target.wait(t, 1, 5*time.Second)
+	if elapsed := target.first().Sub(startTime); elapsed < time.Second+offset {
+		t.Errorf("fired after %v, want %v at least", elapsed, time.Second+offset)
+	}
+
+	assertJobStatus(t, jobID, orm.JobStatusDone)
+	waitJobReleased(t, jobKey, 2*time.Second)
+}
+
+func TestJitterWindowJob(t *testing.T) {
+	scheduler := startJitterScheduler(t)
+	target, server := newJitterTarget(t)
+
+	scheduleJob := newJitterJob(t, "8a4de5b1-92b7-4f0e-8d3c-5e7f1a2b3c4d", server.URL)
+	scheduleJob.TriggerType = "interval"
+	scheduleJob.Expression = "1"
+	scheduleJob.MaxRuns = 2
+	scheduleJob.JitterSeconds = 1
+	scheduleJob.JitterMode = JitterModeRandom
+
+	jobKey := scheduleJitterJob(t, scheduler, scheduleJob)
+
+	// the last run is delivered even though its job has left the queue
+	target.wait(t, 2, 6*time.Second)
+
+	time.Sleep(2 * time.Second)
+	if count := target.count(); count != 2 {
+		t.Errorf("got %d requests, want 2", count)
+	}
+
+	assertJobStatus(t, scheduleJob.JobID, orm.JobStatusDone)
+	waitJobReleased(t, jobKey, time.Second)
+}
+
+func TestJitterWithdrawnJob(t *testing.T) {
+	scheduler := startJitterScheduler(t)
+	target, server := newJitterTarget(t)
+
+	jobID, offset := spreadJobID(3, 1500*time.Millisecond)
+	scheduleJob := newJitterJob(t, jobID, server.URL)
+	scheduleJob.TriggerType = "once"
+	scheduleJob.Expression = "1"
+	scheduleJob.JitterSeconds = 3
+	scheduleJob.JitterMode = JitterModeSpread
+
+	jobKey := scheduleJitterJob(t, scheduler, scheduleJob)
+
+	// pause the job while its fire waits out its jitter
+	time.Sleep(time.Second + offset/2)
+	if err := UnscheduleJob(scheduler, jobKey); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(offset/2 + time.Second)
+	if count := target.count(); count != 0 {
+		t.Errorf("got %d requests, want none", count)
+	}
+
+	assertJobStatus(t, jobID, orm.JobStatusEnable)
+}
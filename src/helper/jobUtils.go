@@ -1,144 +1,304 @@
 package helper
 
 import (
-	"bytes"
 	"context"
-	"io"
-	"net/url"
+	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/cloud01-wu/cgsl/dbx"
-	"github.com/cloud01-wu/cgsl/httpx/client"
 	"github.com/cloud01-wu/cgsl/logger"
-	"github.com/cloud01-wu/scheduler/global"
+	"github.com/cloud01-wu/cgsl/utils"
 	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
 	"github.com/reugn/go-quartz/quartz"
 	"go.uber.org/zap"
 )
 
-func NewJob(
-	scheduler quartz.Scheduler,
-	jobID string,
-	name string,
-	triggerType string,
-	expression string,
-	httpMethod string,
-	httpTargetUrl string,
-	httpRequestPayload string,
-	jsonWebToken string) (quartz.Job, error) {
-	var (
-		err     error
-		trigger quartz.Trigger
-	)
-
-	switch triggerType {
-	case "cron":
-		trigger, err = quartz.NewCronTrigger(expression)
-		if err != nil {
-			return nil, err
-		}
-	case "interval":
-		seconds, err := strconv.ParseInt(expression, 10, 64)
-		if err != nil {
-			return nil, err
-		}
+// newJobDescription describes a job by its ID and version plus a random
+// suffix. Job keys are derived from descriptions, so that keys handed out by
+// different replicas never refer to the same job by accident.
+func newJobDescription(scheduleJob *orm.ScheduleJob) string {
+	return fmt.Sprintf("%s:%d:%s", scheduleJob.JobID, scheduleJob.Version, utils.RandomUUIDString())
+}
 
-		trigger = quartz.NewSimpleTrigger(time.Second * time.Duration(seconds))
-	case "once":
-		seconds, err := strconv.ParseInt(expression, 10, 64)
-		if err != nil {
-			return nil, err
-		}
+// parseJobDescription tells the job ID and version a scheduled job was created for
+func parseJobDescription(description string) (string, int, bool) {
+	tokens := strings.Split(description, ":")
+	if len(tokens) != 3 {
+		return "", 0, false
+	}
+
+	version, err := strconv.Atoi(tokens[1])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return tokens[0], version, true
+}
 
-		trigger = quartz.NewRunOnceTrigger(time.Second * time.Duration(seconds))
-	default:
+// PreparedJob is the job of a schedule job yet to be scheduled, whose key is
+// known beforehand
+type PreparedJob struct {
+	quartz.Job
+	trigger   quartz.Trigger
+	cancelJob context.CancelFunc
+}
+
+// NewJob builds the job of a schedule job and schedules it
+func NewJob(scheduler quartz.Scheduler, scheduleJob orm.ScheduleJob) (quartz.Job, error) {
+	job, err := PrepareJob(scheduleJob)
+	if err != nil {
 		return nil, err
 	}
 
-	headers := map[string]string{}
-	if jsonWebToken != "" {
-		headers["Authorization"] = "Bearer " + jsonWebToken
+	err = job.Schedule(scheduler)
+	if err != nil {
+		return nil, err
 	}
 
-	job := quartz.NewFunctionJob(func(_ context.Context) (int, error) {
-		// update job status
-		if triggerType == "once" {
-			stmt, err := dbx.New().Prepare(`
-				UPDATE schedule_jobs SET Status=? WHERE JobID=?;
-			`)
-			if err != nil {
+	return job, nil
+}
+
+// functionJob runs its function on every fire like quartz.FunctionJob, whose
+// description and key it keeps, without recording the result: fires of a job
+// overlap while waiting out their jitter, and FunctionJob records results
+// unsynchronized
+type functionJob struct {
+	*quartz.FunctionJob[int]
+	function quartz.Function[int]
+}
+
+func newFunctionJob(desc string, function quartz.Function[int]) *functionJob {
+	return &functionJob{
+		FunctionJob: quartz.NewFunctionJobWithDesc(desc, function),
+		function:    function,
+	}
+}
+
+func (job *functionJob) Execute(ctx context.Context) {
+	job.function(ctx)
+}
+
+// PrepareJob builds the job of a schedule job without scheduling it, so that
+// its key is stored along with the job before the job fires
+func PrepareJob(scheduleJob orm.ScheduleJob) (*PreparedJob, error) {
+	jobID := scheduleJob.JobID
+	name := scheduleJob.Name
+	triggerType := scheduleJob.TriggerType
+
+	trigger, err := newJobTrigger(&scheduleJob)
+	if err != nil {
+		return nil, err
+	}
+
+	// a job is done once its validity window ends or its runs run out
+	if window, ok := trigger.(*windowTrigger); ok {
+		window.onExpired = func() {
+			if err := store.New().MarkDone(jobID); err != nil {
 				logger.New().Error("FAILED TO UPDATE JOB STATUS", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
-				return -1, err
 			}
-			defer stmt.Close()
+		}
+	}
 
-			_, err = stmt.Exec(orm.JobStatusDone, jobID)
+	firedTrigger := newFiredTrigger(trigger)
+	jobContext, cancelJob := context.WithCancel(context.Background())
+
+	var job *functionJob
+	job = newFunctionJob(newJobDescription(&scheduleJob), func(ctx context.Context) (int, error) {
+		executionWaitGroup.Add(1)
+		defer executionWaitGroup.Done()
+
+		ctx, cancel := newExecutionContext(ctx)
+		defer cancel()
+
+		scheduledTime := time.Unix(0, firedTrigger.scheduledFireTime(time.Now().UnixNano()))
+
+		// delay the fire within the jitter window, while the fire itself is
+		// still told apart by its scheduled time
+		if offset := JitterOffset(&scheduleJob, scheduledTime); offset > 0 {
+			timer := time.NewTimer(time.Until(scheduledTime.Add(offset)))
+			defer timer.Stop()
+
+			select {
+			case <-timer.C:
+			case <-jobContext.Done():
+				logger.New().Info("FIRE WITHDRAWN DURING JITTER", zap.String("JobID", jobID), zap.String("Name", name), zap.Time("ScheduledTime", scheduledTime))
+				return 0, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+
+		execution := NewJobExecution(jobID, scheduledTime, orm.ExecutionTriggeredBySchedule)
+
+		// deliver the fire only if no other replica did
+		if FireLockEnabled() {
+			claimed, err := claimFire(jobID, scheduledTime)
 			if err != nil {
-				logger.New().Error("FAILED TO UPDATE JOB STATUS", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+				logger.New().Error("FAILED TO CLAIM FIRE", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
 				return -1, err
 			}
+			if !claimed {
+				logger.New().Info("FIRE CLAIMED BY ANOTHER REPLICA", zap.String("JobID", jobID), zap.String("Name", name), zap.Time("ScheduledTime", scheduledTime))
+				return 0, nil
+			}
 		}
 
-		// exec job
-		urlObject, err := url.Parse(httpTargetUrl)
+		// count the run against the max runs of the job
+		counted, err := store.New().CountRun(jobID)
 		if err != nil {
-			logger.New().Error("FAILED TO PARSE TARGET URL", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+			logger.New().Error("FAILED TO COUNT RUN", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
 			return -1, err
 		}
-
-		// enable secure while HTTP scheme is "https"
-		secureEnable := false
-		if strings.EqualFold(urlObject.Scheme, "https") {
-			secureEnable = true
+		if !counted {
+			logger.New().Info("NO RUNS LEFT", zap.String("JobID", jobID), zap.String("Name", name))
+			if err := store.New().MarkDone(jobID); err != nil {
+				logger.New().Error("FAILED TO UPDATE JOB STATUS", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+			}
+			releaseJob(job.Key())
+			return 0, nil
 		}
 
-		httpClient, err := client.New(urlObject.Host, &client.Options{
-			Secure: secureEnable,
-		})
-		if err != nil {
-			logger.New().Error("FAILED TO INIT HTTP CLIENT", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
-			return -1, err
+		// update job status
+		if triggerType == "once" || triggerType == "at" {
+			err := store.New().MarkDone(jobID)
+			if err != nil {
+				logger.New().Error("FAILED TO UPDATE JOB STATUS", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+				return -1, err
+			}
 		}
 
-		payloadData := []byte(httpRequestPayload)
-		res, err := httpClient.ExecuteMethod(
-			context.Background(),
-			httpMethod,
-			strings.TrimPrefix(urlObject.Path, "/"), // trim leading slash
-			client.RequestMetadata{
-				QueryValues:   urlObject.Query(),
-				Headers:       headers,
-				ContentType:   "application/octet-stream",
-				ContentLength: len(payloadData),
-				ContentBody:   bytes.NewReader(payloadData),
-			},
-		)
+		// exec job
+		err = ExecuteJob(ctx, &scheduleJob, execution)
+		recordFire(&scheduleJob, execution)
 		if err != nil {
-			logger.New().Error("FAILED EXECUTE METHOD", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
 			return -1, err
 		}
 
-		if res.StatusCode >= 400 {
-			builder := new(strings.Builder)
-			io.Copy(builder, res.Body)
-			logger.New().Info("FETCH FAILURE HTTP STATUS CODE", zap.String("JobID", jobID), zap.String("Name", name), zap.String("Response", builder.String()))
-		} else {
-			logger.New().Info("JOB ACCOMPLISHED", zap.String("JobID", jobID), zap.String("Name", name))
-		}
-
-		return res.StatusCode, nil
+		return execution.HttpStatusCode, nil
 	})
 
-	if err != nil {
-		return nil, err
+	// jobs running out of fire times release their cancellation on their own,
+	// once the last fire is done waiting out its jitter
+	firedTrigger.onExpired = func(lastFireTime int64) {
+		jitterWindow := time.Duration(scheduleJob.JitterSeconds) * time.Second
+		time.AfterFunc(time.Until(time.Unix(0, lastFireTime).Add(jitterWindow)), func() {
+			releaseJob(job.Key())
+		})
 	}
 
-	err = global.Scheduler.ScheduleJob(context.Background(), job, trigger)
+	return &PreparedJob{
+		Job:       job,
+		trigger:   firedTrigger,
+		cancelJob: cancelJob,
+	}, nil
+}
+
+// Schedule schedules a prepared job on scheduler
+func (job *PreparedJob) Schedule(scheduler quartz.Scheduler) error {
+	// a job firing right away may finish and release itself before
+	// ScheduleJob returns
+	jobCancels.mtx.Lock()
+	jobCancels.cancels[job.Key()] = job.cancelJob
+	jobCancels.mtx.Unlock()
+
+	err := scheduler.ScheduleJob(context.Background(), job.Job, job.trigger)
 	if err != nil {
-		return nil, err
+		releaseJob(job.Key())
+		return err
 	}
 
-	return job, nil
+	return nil
+}
+
+// jobCancels keeps the cancellation of every scheduled job by job key, which
+// withdraws fires of the job waiting out their jitter once it is unscheduled.
+// The queue of go-quartz tells nothing about it, since the last fire of a job
+// may still be waiting after the job left the queue.
+var jobCancels = struct {
+	mtx     sync.Mutex
+	cancels map[int]context.CancelFunc
+}{
+	cancels: map[int]context.CancelFunc{},
+}
+
+// UnscheduleJob removes a job from a scheduler if scheduled, and withdraws its
+// fires waiting out their jitter
+func UnscheduleJob(scheduler quartz.Scheduler, jobKey int) error {
+	releaseJob(jobKey)
+
+	if _, err := scheduler.GetScheduledJob(jobKey); err != nil {
+		return nil
+	}
+
+	return scheduler.DeleteJob(jobKey)
+}
+
+// releaseJob withdraws the fires of a job waiting out their jitter and drops
+// its cancellation
+func releaseJob(jobKey int) {
+	jobCancels.mtx.Lock()
+	defer jobCancels.mtx.Unlock()
+
+	if cancelJob, ok := jobCancels.cancels[jobKey]; ok {
+		cancelJob()
+		delete(jobCancels.cancels, jobKey)
+	}
+}
+
+// jobLocks serializes the changes of jobs to the scheduler by job ID, so that a
+// job updated concurrently ends up scheduled by the latest update only
+var jobLocks = struct {
+	mtx   sync.Mutex
+	locks map[string]*jobLock
+}{
+	locks: map[string]*jobLock{},
+}
+
+type jobLock struct {
+	sync.Mutex
+	// number of callers holding or waiting for the lock
+	refs int
+}
+
+// LockJob locks the changes of a job to the scheduler, from the conditional
+// update of the job until its previous job is unscheduled and its new one is
+// scheduled, and returns the function unlocking them
+func LockJob(jobID string) func() {
+	jobLocks.mtx.Lock()
+	lock, ok := jobLocks.locks[jobID]
+	if !ok {
+		lock = &jobLock{}
+		jobLocks.locks[jobID] = lock
+	}
+	lock.refs++
+	jobLocks.mtx.Unlock()
+
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+
+		jobLocks.mtx.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(jobLocks.locks, jobID)
+		}
+		jobLocks.mtx.Unlock()
+	}
+}
+
+// ClearJobs removes every job from a scheduler, and withdraws their fires
+// waiting out their jitter
+func ClearJobs(scheduler quartz.Scheduler) {
+	jobCancels.mtx.Lock()
+	for jobKey, cancelJob := range jobCancels.cancels {
+		cancelJob()
+		delete(jobCancels.cancels, jobKey)
+	}
+	jobCancels.mtx.Unlock()
+
+	scheduler.Clear()
 }
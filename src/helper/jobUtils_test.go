@@ -0,0 +1,54 @@
+package helper
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockJob(t *testing.T) {
+	const lockers = 8
+
+	var (
+		wg      sync.WaitGroup
+		mtx     sync.Mutex
+		holding = map[string]int{}
+		overlap bool
+	)
+
+	for i := 0; i < lockers; i++ {
+		for _, jobID := range []string{"job-1", "job-2"} {
+			wg.Add(1)
+			go func(jobID string) {
+				defer wg.Done()
+
+				unlock := LockJob(jobID)
+				defer unlock()
+
+				mtx.Lock()
+				holding[jobID]++
+				if holding[jobID] > 1 {
+					overlap = true
+				}
+				mtx.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mtx.Lock()
+				holding[jobID]--
+				mtx.Unlock()
+			}(jobID)
+		}
+	}
+	wg.Wait()
+
+	if overlap {
+		t.Error("a job is locked by more than one caller at once")
+	}
+
+	jobLocks.mtx.Lock()
+	defer jobLocks.mtx.Unlock()
+	if len(jobLocks.locks) != 0 {
+		t.Errorf("got %d locks left, want none", len(jobLocks.locks))
+	}
+}
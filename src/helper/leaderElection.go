@@ -0,0 +1,161 @@
+package helper
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloud01-wu/cgsl/dbx"
+	"github.com/cloud01-wu/cgsl/logger"
+	"go.uber.org/zap"
+)
+
+// name of the lease replicas compete for
+const schedulerLeaseName = "scheduler"
+
+// LeaderElection elects a single leader among replicas through a lease row in
+// MySQL. Lease expiry is evaluated by the clock of MySQL, so that the clocks of
+// replicas do not matter.
+type LeaderElection struct {
+	holder        string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	leader        atomic.Bool
+
+	// OnElected and OnDemoted are invoked from Run on leadership changes
+	OnElected func()
+	OnDemoted func()
+}
+
+func NewLeaderElection(holder string, leaseDuration time.Duration) *LeaderElection {
+	return &LeaderElection{
+		holder:        holder,
+		leaseDuration: leaseDuration,
+		renewInterval: leaseDuration / 3,
+	}
+}
+
+func (election *LeaderElection) IsLeader() bool {
+	return election.leader.Load()
+}
+
+// Run campaigns for and renews the lease until ctx is done, and releases the
+// lease afterward. A follower takes over within a lease duration plus a renew
+// interval after the leader dies.
+func (election *LeaderElection) Run(ctx context.Context) {
+	var lastRenewTime time.Time
+
+	ticker := time.NewTicker(election.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		held, err := election.acquire()
+		switch {
+		case err != nil:
+			logger.New().Error("FAILED TO RENEW LEADER LEASE", zap.String("Holder", election.holder), zap.Error(err))
+
+			// step down before the lease may have been taken over
+			if election.IsLeader() && time.Since(lastRenewTime) >= election.leaseDuration-election.renewInterval {
+				election.demote()
+			}
+		case held:
+			lastRenewTime = time.Now()
+			if !election.IsLeader() {
+				election.elect()
+			}
+		default:
+			if election.IsLeader() {
+				election.demote()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if election.IsLeader() {
+				election.demote()
+				if err := election.release(); err != nil {
+					logger.New().Error("FAILED TO RELEASE LEADER LEASE", zap.String("Holder", election.holder), zap.Error(err))
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (election *LeaderElection) elect() {
+	election.leader.Store(true)
+	logger.New().Warn("ELECTED AS LEADER", zap.String("Holder", election.holder))
+
+	if election.OnElected != nil {
+		election.OnElected()
+	}
+}
+
+func (election *LeaderElection) demote() {
+	election.leader.Store(false)
+	logger.New().Warn("DEMOTED TO FOLLOWER", zap.String("Holder", election.holder))
+
+	if election.OnDemoted != nil {
+		election.OnDemoted()
+	}
+}
+
+// acquire takes the lease over when it is expired, or extends it when it is
+// already held, and reports whether the lease is held afterward
+func (election *LeaderElection) acquire() (bool, error) {
+	// assignments are evaluated from left to right, hence ExpireTime is only
+	// extended once Holder is settled
+	stmt1, err := dbx.New().Prepare(`
+		INSERT INTO scheduler_leases (Name,Holder,ExpireTime)
+		VALUES (?,?,ROUND(UNIX_TIMESTAMP(NOW(3))*1000)+?)
+		ON DUPLICATE KEY UPDATE
+		Holder=IF(Holder=VALUES(Holder) OR ExpireTime<ROUND(UNIX_TIMESTAMP(NOW(3))*1000),VALUES(Holder),Holder),
+		ExpireTime=IF(Holder=VALUES(Holder),VALUES(ExpireTime),ExpireTime)
+		;
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt1.Close()
+
+	_, err = stmt1.Exec(schedulerLeaseName, election.holder, election.leaseDuration.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+
+	stmt2, err := dbx.New().Prepare(`
+		SELECT Holder
+		FROM scheduler_leases
+		WHERE Name=?
+		;
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt2.Close()
+
+	holder := ""
+	err = stmt2.QueryRow(schedulerLeaseName).Scan(&holder)
+	if err != nil {
+		return false, err
+	}
+
+	return holder == election.holder, nil
+}
+
+// release expires the lease immediately, so that a follower takes over without
+// waiting for the lease to expire
+func (election *LeaderElection) release() error {
+	stmt, err := dbx.New().Prepare(`
+		UPDATE scheduler_leases SET ExpireTime=0 WHERE Name=? AND Holder=?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(schedulerLeaseName, election.holder)
+	return err
+}
@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/store"
+	"go.uber.org/zap"
+)
+
+// actions taken on fires missed while the scheduler was down
+const (
+	MisfireActionSkip     = "skip"
+	MisfireActionFireOnce = "fireOnce"
+	MisfireActionFireAll  = "fireAll"
+)
+
+// default values of a misfire policy
+const (
+	DefaultMisfireAction  = MisfireActionSkip
+	DefaultMisfireMaxRuns = 10
+)
+
+// HandleMisfires evaluates the misfire policy of a job being restored, and
+// delivers the fires missed since its latest fire in background. Missed fires
+// are delivered one after another in chronological order. An at job which is
+// past due is left to the misfire policy as well.
+func HandleMisfires(scheduleJob orm.ScheduleJob, now time.Time) error {
+	// once jobs still fire after being restored
+	if scheduleJob.TriggerType == "once" {
+		return nil
+	}
+
+	limit := 0
+	switch scheduleJob.MisfireAction {
+	case MisfireActionFireOnce:
+		limit = 1
+	case MisfireActionFireAll:
+		limit = scheduleJob.MisfireMaxRuns
+		if limit < 1 {
+			limit = 1
+		}
+	default:
+		return nil
+	}
+
+	// fires before the latest update belong to former settings
+	since := time.Unix(scheduleJob.UpdateTime, 0)
+	if lastFireTime := time.UnixMilli(scheduleJob.LastFireTime); lastFireTime.After(since) {
+		since = lastFireTime
+	}
+
+	fireTimes, err := MissedFireTimes(&scheduleJob, since, now, limit)
+	if err != nil {
+		return err
+	}
+
+	if len(fireTimes) == 0 {
+		return nil
+	}
+
+	logger.New().Warn("JOB MISFIRED", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.String("MisfireAction", scheduleJob.MisfireAction), zap.Int("Fires", len(fireTimes)))
+
+	executionWaitGroup.Add(1)
+	go func() {
+		defer executionWaitGroup.Done()
+
+		for _, fireTime := range fireTimes {
+			// leave the rest once shutdown cancels executions
+			if executionContext.Err() != nil {
+				return
+			}
+
+			deliverMisfire(scheduleJob, fireTime)
+		}
+	}()
+
+	return nil
+}
+
+// deliverMisfire executes a job on behalf of a fire missed at the scheduled time
+func deliverMisfire(scheduleJob orm.ScheduleJob, scheduledTime time.Time) {
+	if !claimMissedFire(&scheduleJob, scheduledTime) {
+		return
+	}
+
+	execution := NewJobExecution(scheduleJob.JobID, scheduledTime, orm.ExecutionTriggeredByMisfire)
+	runJob(scheduleJob, execution, false)
+}
+
+// claimMissedFire claims a fire missed at the scheduled time and counts it
+// against the max runs of the job, telling whether it is to be delivered
+func claimMissedFire(scheduleJob *orm.ScheduleJob, scheduledTime time.Time) bool {
+	jobID := scheduleJob.JobID
+	name := scheduleJob.Name
+
+	// another replica may have delivered the fire already
+	if FireLockEnabled() {
+		claimed, err := claimFire(jobID, scheduledTime)
+		if err != nil {
+			logger.New().Error("FAILED TO CLAIM FIRE", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+			return false
+		}
+		if !claimed {
+			logger.New().Info("FIRE CLAIMED BY ANOTHER REPLICA", zap.String("JobID", jobID), zap.String("Name", name), zap.Time("ScheduledTime", scheduledTime))
+			return false
+		}
+	}
+
+	counted, err := store.New().CountRun(jobID)
+	if err != nil {
+		logger.New().Error("FAILED TO COUNT RUN", zap.String("JobID", jobID), zap.String("Name", name), zap.Error(err))
+		return false
+	}
+	if !counted {
+		logger.New().Info("NO RUNS LEFT", zap.String("JobID", jobID), zap.String("Name", name))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,144 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+const (
+	// timeout of requesting an access token
+	oauth2TokenTimeout = 30 * time.Second
+	// margin before the expiry of an access token it is refreshed within, so
+	// that it does not expire in flight
+	oauth2TokenExpiryMargin = 30 * time.Second
+	// max length of token responses being read
+	maxOAuth2TokenResponseLength = 1 << 20
+)
+
+// oauth2Client identifies the access tokens shared by jobs authenticating as
+// the same client
+type oauth2Client struct {
+	tokenUrl     string
+	clientID     string
+	clientSecret string
+	scopes       string
+}
+
+type oauth2Token struct {
+	mtx         sync.Mutex
+	accessToken string
+	// zero if the token server told no lifetime
+	expiry time.Time
+}
+
+var (
+	oauth2TokensMtx  sync.Mutex
+	oauth2Tokens     = map[oauth2Client]*oauth2Token{}
+	oauth2HttpClient = &http.Client{Timeout: oauth2TokenTimeout}
+)
+
+func newOAuth2Client(scheduleJob *orm.ScheduleJob) oauth2Client {
+	return oauth2Client{
+		tokenUrl:     scheduleJob.OAuth2TokenUrl,
+		clientID:     scheduleJob.OAuth2ClientID,
+		clientSecret: scheduleJob.OAuth2ClientSecret,
+		scopes:       scheduleJob.OAuth2Scopes,
+	}
+}
+
+// getOAuth2Token returns the cached access token of the client of a job, and
+// requests a new one once it expires or it is the one rejected by the target
+func getOAuth2Token(ctx context.Context, scheduleJob *orm.ScheduleJob, rejectedToken string) (string, error) {
+	client := newOAuth2Client(scheduleJob)
+
+	oauth2TokensMtx.Lock()
+	token, ok := oauth2Tokens[client]
+	if !ok {
+		token = &oauth2Token{}
+		oauth2Tokens[client] = token
+	}
+	oauth2TokensMtx.Unlock()
+
+	// jobs of the same client firing together wait for a single request
+	token.mtx.Lock()
+	defer token.mtx.Unlock()
+
+	if token.accessToken != "" && token.accessToken != rejectedToken &&
+		(token.expiry.IsZero() || time.Now().Before(token.expiry)) {
+		return token.accessToken, nil
+	}
+
+	accessToken, expiresIn, err := requestOAuth2Token(ctx, client)
+	if err != nil {
+		return "", err
+	}
+
+	token.accessToken = accessToken
+	token.expiry = time.Time{}
+	if expiresIn > 0 {
+		token.expiry = time.Now().Add(expiresIn - oauth2TokenExpiryMargin)
+	}
+
+	return accessToken, nil
+}
+
+// requestOAuth2Token performs the client credentials grant (RFC 6749 section
+// 4.4), authenticating the client by HTTP Basic
+func requestOAuth2Token(ctx context.Context, client oauth2Client) (string, time.Duration, error) {
+	form := url.Values{"grant_type": []string{"client_credentials"}}
+	if client.scopes != "" {
+		form.Set("scope", client.scopes)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, client.tokenUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(client.clientID), url.QueryEscape(client.clientSecret))
+
+	response, err := oauth2HttpClient.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	defer drainResponseBody(response.Body)
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxOAuth2TokenResponseLength))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint responded %s: %s", response.Status, truncateResponseBody(string(body)))
+	}
+
+	result := struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if result.AccessToken == "" {
+		return "", 0, errors.New("token endpoint responded no access token")
+	}
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("token endpoint responded unsupported token type %s", result.TokenType)
+	}
+
+	return result.AccessToken, time.Duration(result.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/signature"
+)
+
+const (
+	// max number of signing secrets of a job, which are active at once while
+	// rotating
+	MaxSigningSecrets = 5
+	// min length of signing secrets
+	MinSigningSecretLength = 16
+)
+
+// ValidateSigningSecrets checks the secrets signing requests of a job
+func ValidateSigningSecrets(secrets []string) error {
+	if len(secrets) > MaxSigningSecrets {
+		return fmt.Errorf("at most %d signing secrets are allowed", MaxSigningSecrets)
+	}
+
+	for _, secret := range secrets {
+		if len(secret) < MinSigningSecretLength {
+			return fmt.Errorf("signing secrets must be at least %d characters", MinSigningSecretLength)
+		}
+	}
+
+	return nil
+}
+
+func EncodeSigningSecrets(secrets []string) (string, error) {
+	if len(secrets) == 0 {
+		return "", nil
+	}
+
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func DecodeSigningSecrets(secrets string) []string {
+	result := []string{}
+	if secrets == "" {
+		return result
+	}
+
+	// rows are always written by EncodeSigningSecrets
+	json.Unmarshal([]byte(secrets), &result)
+	return result
+}
+
+// signRequest stamps the request of a job to its parsed target URL with the
+// time now and signs it by every signing secret of the job, if any
+func signRequest(scheduleJob *orm.ScheduleJob, urlObject *url.URL, headers map[string]string, now time.Time) {
+	secrets := DecodeSigningSecrets(scheduleJob.SigningSecrets)
+	if len(secrets) == 0 {
+		return
+	}
+
+	path := "/"
+	if urlObject.EscapedPath() != "" {
+		path = urlObject.EscapedPath()
+	}
+
+	timestamp := now.Unix()
+	headers[signature.TimestampHeader] = strconv.FormatInt(timestamp, 10)
+	headers[signature.SignatureHeader] = signature.SignatureHeaderValue(
+		secrets,
+		scheduleJob.HttpMethod,
+		path,
+		timestamp,
+		[]byte(scheduleJob.HttpRequestBody),
+	)
+}
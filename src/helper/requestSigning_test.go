@@ -0,0 +1,91 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/signature"
+)
+
+// TestSignRequest sends signed requests of jobs to a receiver verifying them
+// by the signature package, so that both sides agree on the canonical string
+func TestSignRequest(t *testing.T) {
+	const (
+		oldSecret = "old-secret-0123456789"
+		newSecret = "new-secret-0123456789"
+	)
+
+	var verifyErr error
+	receiverSecrets := []string{newSecret}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		verifyErr = signature.VerifyRequest(r, receiverSecrets)
+	}))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		signingSecrets []string
+	}{
+		{name: "post", method: http.MethodPost, path: "/hooks/a", body: `{"ping":true}`, signingSecrets: []string{newSecret}},
+		{name: "get without body", method: http.MethodGet, path: "/hooks/a", signingSecrets: []string{newSecret}},
+		{name: "root path", method: http.MethodPost, path: "", body: `{"ping":true}`, signingSecrets: []string{newSecret}},
+		{name: "query", method: http.MethodPut, path: "/hooks/a?x=1&y=b%20c", body: `{"ping":true}`, signingSecrets: []string{newSecret}},
+		{name: "escaped path", method: http.MethodPost, path: "/hooks/a%20b/%C3%A9", body: `{"ping":true}`, signingSecrets: []string{newSecret}},
+		{name: "rotation", method: http.MethodPost, path: "/hooks/a", body: `{"ping":true}`, signingSecrets: []string{oldSecret, newSecret}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signingSecrets, err := EncodeSigningSecrets(test.signingSecrets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scheduleJob := &orm.ScheduleJob{
+				JobID:           "3b9e2d4f-7c1a-4e8b-a6d5-2f0c9e8b7a61",
+				Name:            t.Name(),
+				HttpMethod:      test.method,
+				HttpTargetUrl:   server.URL + test.path,
+				HttpRequestBody: test.body,
+				ContentType:     DefaultContentType,
+				TimeoutSeconds:  DefaultTimeoutSeconds,
+				SigningSecrets:  signingSecrets,
+			}
+
+			urlObject, err := url.Parse(scheduleJob.HttpTargetUrl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			headers := map[string]string{}
+			signRequest(scheduleJob, urlObject, headers, time.Now())
+
+			verifyErr = errors.New("no request received")
+			if _, err := fetchWithTimeout(context.Background(), scheduleJob, urlObject, headers); err != nil {
+				t.Fatal(err)
+			}
+			if verifyErr != nil {
+				t.Errorf("failed to verify request: %v", verifyErr)
+			}
+		})
+	}
+}
+
+func TestSignRequestWithoutSecrets(t *testing.T) {
+	headers := map[string]string{}
+	urlObject := &url.URL{Scheme: "http", Host: "localhost", Path: "/hooks/a"}
+	signRequest(&orm.ScheduleJob{HttpMethod: http.MethodPost, HttpTargetUrl: urlObject.String()}, urlObject, headers, time.Now())
+
+	if len(headers) != 0 {
+		t.Errorf("got headers %v of job without signing secrets", headers)
+	}
+}
@@ -0,0 +1,135 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+// default values of a retry policy
+const (
+	DefaultRetryMaxAttempts    = 1
+	DefaultRetryInitialBackoff = 1000  // millisecond
+	DefaultRetryMultiplier     = 2.0   // times
+	DefaultRetryMaxBackoff     = 60000 // millisecond
+)
+
+var DefaultRetryStatusCodes = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	Multiplier     float64
+	MaxBackoff     time.Duration
+	StatusCodes    []int
+}
+
+func NewRetryPolicy(scheduleJob *orm.ScheduleJob) *RetryPolicy {
+	policy := &RetryPolicy{
+		MaxAttempts:    scheduleJob.RetryMaxAttempts,
+		InitialBackoff: time.Duration(scheduleJob.RetryInitialBackoff) * time.Millisecond,
+		Multiplier:     scheduleJob.RetryMultiplier,
+		MaxBackoff:     time.Duration(scheduleJob.RetryMaxBackoff) * time.Millisecond,
+		StatusCodes:    SplitStatusCodes(scheduleJob.RetryStatusCodes),
+	}
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Multiplier < 1 {
+		policy.Multiplier = 1
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+
+	return policy
+}
+
+// Retryable reports whether an attempt ended with the given status code or
+// error deserves another attempt
+func (policy *RetryPolicy) Retryable(statusCode int, err error) bool {
+	if err != nil {
+		// a canceled execution must not be retried, whereas a timed out attempt may
+		return !errors.Is(err, context.Canceled)
+	}
+
+	for _, code := range policy.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Backoff returns the delay before the attempt following the given one (starting from 1)
+func (policy *RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := float64(policy.InitialBackoff) * math.Pow(policy.Multiplier, float64(attempt-1))
+	if backoff > float64(policy.MaxBackoff) {
+		return policy.MaxBackoff
+	}
+
+	return time.Duration(backoff)
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, which is either
+// delay seconds or an HTTP date
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := date.Sub(now)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+func JoinStatusCodes(statusCodes []int) string {
+	tokens := make([]string, 0, len(statusCodes))
+	for _, statusCode := range statusCodes {
+		tokens = append(tokens, strconv.Itoa(statusCode))
+	}
+
+	return strings.Join(tokens, ",")
+}
+
+func SplitStatusCodes(statusCodes string) []int {
+	result := []int{}
+	for _, token := range strings.Split(statusCodes, ",") {
+		statusCode, err := strconv.Atoi(strings.TrimSpace(token))
+		if err != nil {
+			continue
+		}
+		result = append(result, statusCode)
+	}
+
+	return result
+}
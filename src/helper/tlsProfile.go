@@ -0,0 +1,132 @@
+package helper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// TlsProfile configures the TLS of requests to targets, with files in PEM
+type TlsProfile struct {
+	// CA bundle trusted in place of the system roots
+	CAFile string `json:"caFile"`
+	// client certificate and key presented for mutual TLS
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	// name verified against the certificate of targets in place of their host
+	ServerName string `json:"serverName"`
+	// 1.0, 1.1, 1.2 (default) or 1.3
+	MinVersion string `json:"minVersion"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var ErrUnknownTlsProfile = errors.New("unknown TLS profile")
+
+// TLS configurations of profiles keyed by name, which are loaded once on start
+var tlsProfileConfigs = map[string]*tls.Config{}
+
+// newTlsConfig loads the files of a TLS profile
+func newTlsConfig(profile *TlsProfile) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: profile.ServerName,
+	}
+
+	if profile.MinVersion != "" {
+		minVersion, ok := tlsVersions[profile.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid minVersion %s", profile.MinVersion)
+		}
+		tlsConfig.MinVersion = minVersion
+	}
+
+	if profile.CAFile != "" {
+		caBundle, err := os.ReadFile(profile.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("no certificates found in %s", profile.CAFile)
+		}
+	}
+
+	if profile.CertFile != "" || profile.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(profile.CertFile, profile.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// LoadTlsProfiles loads the TLS profiles kept in a JSON file as an object
+// keyed by profile name
+func LoadTlsProfiles(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	profiles := map[string]*TlsProfile{}
+	err = json.Unmarshal(content, &profiles)
+	if err != nil {
+		return err
+	}
+
+	tlsConfigs := map[string]*tls.Config{}
+	for name, profile := range profiles {
+		if name == "" || profile == nil {
+			return errors.New("TLS profiles must be named objects")
+		}
+
+		tlsConfig, err := newTlsConfig(profile)
+		if err != nil {
+			return fmt.Errorf("TLS profile %s: %w", name, err)
+		}
+		tlsConfigs[name] = tlsConfig
+	}
+
+	tlsProfileConfigs = tlsConfigs
+	return nil
+}
+
+// TlsProfileExists tells whether a TLS profile is loaded
+func TlsProfileExists(name string) bool {
+	_, ok := tlsProfileConfigs[name]
+	return ok
+}
+
+// TlsProfileNames lists the TLS profiles loaded
+func TlsProfileNames() []string {
+	names := make([]string, 0, len(tlsProfileConfigs))
+	for name := range tlsProfileConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// tlsProfileConfig returns the TLS configuration of a profile
+func tlsProfileConfig(name string) (*tls.Config, error) {
+	tlsConfig, ok := tlsProfileConfigs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownTlsProfile, name)
+	}
+
+	return tlsConfig, nil
+}
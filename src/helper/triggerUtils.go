@@ -0,0 +1,265 @@
+package helper
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/reugn/go-quartz/quartz"
+)
+
+// default time zone of cron triggers
+const DefaultTimeZone = "UTC"
+
+// LoadTimeZone loads the location of an IANA time zone name
+func LoadTimeZone(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		timeZone = DefaultTimeZone
+	}
+
+	// "Local" depends on the host, which is exactly what a time zone avoids
+	if timeZone == "Local" {
+		return nil, errors.New("unknown time zone Local")
+	}
+
+	return time.LoadLocation(timeZone)
+}
+
+// NewTrigger builds the go-quartz trigger of a job
+func NewTrigger(triggerType string, expression string, timeZone string) (quartz.Trigger, error) {
+	switch triggerType {
+	case "cron":
+		location, err := LoadTimeZone(timeZone)
+		if err != nil {
+			return nil, err
+		}
+
+		return quartz.NewCronTriggerWithLoc(expression, location)
+	case "interval":
+		seconds, err := strconv.ParseInt(expression, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return quartz.NewSimpleTrigger(time.Second * time.Duration(seconds)), nil
+	case "once":
+		seconds, err := strconv.ParseInt(expression, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return quartz.NewRunOnceTrigger(time.Second * time.Duration(seconds)), nil
+	case "at":
+		fireTime, err := ParseFireTime(expression)
+		if err != nil {
+			return nil, err
+		}
+
+		return &atTrigger{fireTime: fireTime.UnixNano()}, nil
+	default:
+		return nil, errors.New("unknown trigger type " + triggerType)
+	}
+}
+
+// ParseFireTime parses the expression of an at trigger, which is an RFC 3339
+// timestamp
+func ParseFireTime(expression string) (time.Time, error) {
+	return time.Parse(time.RFC3339, expression)
+}
+
+// atTrigger fires once at a fixed time (epoch in nanosecond). A fire time
+// which has just passed fires immediately.
+type atTrigger struct {
+	fireTime int64
+	expired  atomic.Bool
+}
+
+func (trigger *atTrigger) NextFireTime(prev int64) (int64, error) {
+	if trigger.expired.Swap(true) {
+		return 0, errors.New("At trigger is expired")
+	}
+
+	return trigger.fireTime, nil
+}
+
+func (trigger *atTrigger) Description() string {
+	return "AtTrigger at: " + strconv.FormatInt(trigger.fireTime, 10)
+}
+
+// MaxPreviewCount limits the number of fire times a preview computes
+const MaxPreviewCount = 100
+
+// NextFireTimes returns up to count fire times of a trigger following prev
+// (epoch in nanosecond), stopping early once the trigger expires
+func NextFireTimes(trigger quartz.Trigger, prev int64, count int) []time.Time {
+	fireTimes := []time.Time{}
+	for len(fireTimes) < count {
+		// an at trigger may hand out a fire time which is already behind
+		next, err := trigger.NextFireTime(prev)
+		if err != nil || next <= prev {
+			break
+		}
+
+		fireTimes = append(fireTimes, time.Unix(0, next))
+		prev = next
+	}
+
+	return fireTimes
+}
+
+// PreviewJob returns up to count upcoming fire times of a scheduled job, given
+// the next run time (epoch in nanosecond) the scheduler holds for it
+func PreviewJob(scheduleJob *orm.ScheduleJob, nextRunTime int64, count int) ([]time.Time, error) {
+	trigger, err := newJobTrigger(scheduleJob)
+	if err != nil {
+		return nil, err
+	}
+
+	if count < 1 {
+		return []time.Time{}, nil
+	}
+
+	fireTimes := []time.Time{time.Unix(0, nextRunTime)}
+
+	// once and at triggers have nothing left after their only fire
+	if scheduleJob.TriggerType == "once" || scheduleJob.TriggerType == "at" {
+		return jitterFireTimes(scheduleJob, fireTimes), nil
+	}
+
+	// the next run has been handed out already
+	if window, ok := trigger.(*windowTrigger); ok && window.remaining > 0 {
+		window.remaining--
+	}
+
+	fireTimes = append(fireTimes, NextFireTimes(trigger, nextRunTime, count-1)...)
+	return jitterFireTimes(scheduleJob, fireTimes), nil
+}
+
+// EstimateJob returns up to count fire times of a job following now, as if the
+// job was scheduled now
+func EstimateJob(scheduleJob *orm.ScheduleJob, now time.Time, count int) ([]time.Time, error) {
+	trigger, err := newJobTrigger(scheduleJob)
+	if err != nil {
+		return nil, err
+	}
+
+	return jitterFireTimes(scheduleJob, NextFireTimes(trigger, now.UnixNano(), count)), nil
+}
+
+// JobExpired reports whether a job has nothing left to fire after now, such as
+// an at job past due, or a job whose validity window ended or whose runs ran out
+func JobExpired(scheduleJob *orm.ScheduleJob, now time.Time) bool {
+	fireTimes, err := EstimateJob(scheduleJob, now, 1)
+	return err == nil && len(fireTimes) == 0
+}
+
+// maxMissedFireTimes bounds the scan for fire times missed during a long gap
+const maxMissedFireTimes = 100000
+
+// LastMissedFireTime returns the latest fire time of a job after since and not
+// after until, as if the job had been scheduled at since
+func LastMissedFireTime(scheduleJob *orm.ScheduleJob, since time.Time, until time.Time) (time.Time, bool, error) {
+	fireTimes, err := MissedFireTimes(scheduleJob, since, until, 1)
+	if err != nil || len(fireTimes) == 0 {
+		return time.Time{}, false, err
+	}
+
+	return fireTimes[0], true, nil
+}
+
+// MissedFireTimes returns the latest limit fire times of a job after since and
+// not after until in chronological order, as if the job had been scheduled at
+// since
+func MissedFireTimes(scheduleJob *orm.ScheduleJob, since time.Time, until time.Time, limit int) ([]time.Time, error) {
+	// fire times have to match the ones of the scheduled job
+	trigger, err := newJobTrigger(scheduleJob)
+	if err != nil {
+		return nil, err
+	}
+
+	fireTimes := []time.Time{}
+	if limit < 1 {
+		return fireTimes, nil
+	}
+
+	prev := since.UnixNano()
+	for i := 0; i < maxMissedFireTimes; i++ {
+		next, err := trigger.NextFireTime(prev)
+		if err != nil || next <= prev || next > until.UnixNano() {
+			break
+		}
+
+		if len(fireTimes) == limit {
+			fireTimes = fireTimes[1:]
+		}
+		fireTimes = append(fireTimes, time.Unix(0, next))
+		prev = next
+	}
+
+	return fireTimes, nil
+}
+
+// firedTrigger wraps a quartz.Trigger and remembers the fire times handed out
+// to the scheduler, so that a running job is able to tell its scheduled time.
+type firedTrigger struct {
+	quartz.Trigger
+	mtx       sync.Mutex
+	fireTimes []int64
+
+	// onExpired is invoked with the last fire time handed out, once the
+	// trigger runs out of fire times
+	onExpired func(lastFireTime int64)
+}
+
+func newFiredTrigger(trigger quartz.Trigger) *firedTrigger {
+	return &firedTrigger{
+		Trigger: trigger,
+	}
+}
+
+func (trigger *firedTrigger) NextFireTime(prev int64) (int64, error) {
+	next, err := trigger.Trigger.NextFireTime(prev)
+
+	trigger.mtx.Lock()
+	defer trigger.mtx.Unlock()
+
+	if err != nil {
+		if trigger.onExpired != nil {
+			trigger.onExpired(prev)
+			trigger.onExpired = nil
+		}
+		return next, err
+	}
+
+	// the scheduler computes the next fire time right after dispatching the
+	// current one, so keeping the latest two values is sufficient
+	trigger.fireTimes = append(trigger.fireTimes, next)
+	if len(trigger.fireTimes) > 2 {
+		trigger.fireTimes = trigger.fireTimes[len(trigger.fireTimes)-2:]
+	}
+
+	return next, nil
+}
+
+// scheduledFireTime returns the latest fire time (epoch in nanosecond) which is
+// not after now, or now itself if no such fire time was handed out.
+func (trigger *firedTrigger) scheduledFireTime(now int64) int64 {
+	trigger.mtx.Lock()
+	defer trigger.mtx.Unlock()
+
+	scheduled := int64(-1)
+	for _, fireTime := range trigger.fireTimes {
+		if fireTime <= now && fireTime > scheduled {
+			scheduled = fireTime
+		}
+	}
+
+	if scheduled < 0 {
+		return now
+	}
+
+	return scheduled
+}
@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/reugn/go-quartz/quartz"
+)
+
+// windowTrigger restricts the fire times of a trigger to a validity window
+// (epoch in nanosecond, 0 if unbounded) and to the runs left (negative if
+// unlimited)
+type windowTrigger struct {
+	quartz.Trigger
+	startAt int64
+	endAt   int64
+
+	mtx       sync.Mutex
+	remaining int
+	handedOut bool
+
+	// onExpired is invoked once the trigger runs out of fire times after
+	// handing out some
+	onExpired func()
+}
+
+func (trigger *windowTrigger) NextFireTime(prev int64) (int64, error) {
+	trigger.mtx.Lock()
+	defer trigger.mtx.Unlock()
+
+	next, err := trigger.nextFireTime(prev)
+	if err != nil {
+		if trigger.handedOut && trigger.onExpired != nil {
+			// the scheduler loop must not wait for it
+			go trigger.onExpired()
+			trigger.onExpired = nil
+		}
+		return 0, err
+	}
+
+	if trigger.remaining > 0 {
+		trigger.remaining--
+	}
+	trigger.handedOut = true
+
+	return next, nil
+}
+
+func (trigger *windowTrigger) nextFireTime(prev int64) (int64, error) {
+	if trigger.remaining == 0 {
+		return 0, errors.New("no runs left")
+	}
+
+	// a fire time right at the start of the window counts
+	if prev < trigger.startAt {
+		prev = trigger.startAt - 1
+	}
+
+	next, err := trigger.Trigger.NextFireTime(prev)
+	if err != nil {
+		return 0, err
+	}
+
+	if next < trigger.startAt || (trigger.endAt != 0 && next > trigger.endAt) {
+		return 0, errors.New("fire time is out of the validity window")
+	}
+
+	return next, nil
+}
+
+func (trigger *windowTrigger) Description() string {
+	return trigger.Trigger.Description() + " within window"
+}
+
+// newJobTrigger builds the trigger a job is scheduled with, bounded by the
+// validity window and the runs left of the job
+func newJobTrigger(scheduleJob *orm.ScheduleJob) (quartz.Trigger, error) {
+	trigger, err := NewTrigger(scheduleJob.TriggerType, scheduleJob.Expression, scheduleJob.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	// replicas claiming fires have to agree on fire times, and interval jobs
+	// with a start time fire every interval from it
+	if FireLockEnabled() || scheduleJob.StartAt != 0 {
+		trigger, err = newAlignedTrigger(scheduleJob, trigger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if scheduleJob.StartAt == 0 && scheduleJob.EndAt == 0 && scheduleJob.MaxRuns == 0 {
+		return trigger, nil
+	}
+
+	window := &windowTrigger{
+		Trigger:   trigger,
+		remaining: -1,
+	}
+	if scheduleJob.StartAt != 0 {
+		window.startAt = time.Unix(scheduleJob.StartAt, 0).UnixNano()
+	}
+	if scheduleJob.EndAt != 0 {
+		window.endAt = time.Unix(scheduleJob.EndAt, 0).UnixNano()
+	}
+	if scheduleJob.MaxRuns != 0 {
+		window.remaining = max(scheduleJob.MaxRuns-scheduleJob.RunCount, 0)
+	}
+
+	return window, nil
+}
@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
+	_ "time/tzdata" // embed IANA time zone database for cron triggers
 
-	"github.com/blockloop/scan"
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/mysql"
+	pgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/reugn/go-quartz/quartz"
 
@@ -20,10 +26,13 @@ import (
 	"github.com/cloud01-wu/cgsl/env"
 	"github.com/cloud01-wu/cgsl/httpx/server"
 	"github.com/cloud01-wu/cgsl/logger"
+	"github.com/cloud01-wu/cgsl/utils"
 	v1 "github.com/cloud01-wu/scheduler/controllers/v1"
 	"github.com/cloud01-wu/scheduler/global"
 	"github.com/cloud01-wu/scheduler/helper"
 	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/secret"
+	"github.com/cloud01-wu/scheduler/store"
 	"go.uber.org/zap"
 )
 
@@ -36,7 +45,100 @@ var (
 	interrupt chan os.Signal
 )
 
+// default migrations of each database driver
+var defaultMigrations = map[string]struct {
+	folder  string
+	version uint
+}{
+	store.DriverMySQL:    {folder: "/opt/db/migrations", version: 19},
+	store.DriverPostgres: {folder: "/opt/db/migrations-postgres", version: 7},
+	store.DriverSQLite:   {folder: "/opt/db/migrations-sqlite", version: 7},
+}
+
+// migrateDatabase migrates a database to the version of the migrations in
+// folder
+func migrateDatabase(
+	driver database.Driver,
+	dbName string,
+	migrationFolder string,
+	migrationVersion uint,
+) error {
+	migrationFolder = strings.TrimSuffix(migrationFolder, string(filepath.Separator))
+	logger.New().Debug("MIGRATION", zap.String("FOLDER", migrationFolder))
+	m, err := migrate.NewWithDatabaseInstance(
+		fmt.Sprintf("file://%s", migrationFolder),
+		dbName,
+		driver)
+
+	if err != nil {
+		return err
+	} else {
+		err := m.Migrate(migrationVersion)
+		if err != nil {
+			if err.Error() != "no change" {
+				logger.New().Info("MIGRATION", zap.String("RESULT", err.Error()))
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// initDatabase initializes the store of the database driver
 func initDatabase(
+	driver string,
+	endpoint string,
+	username string,
+	password string,
+	dbName string,
+	sslMode string,
+	maxOpenConns int,
+	maxIdleConns int,
+	sqlitePath string,
+	migrationFolder string,
+	migrationVersion uint,
+) error {
+	switch driver {
+	case store.DriverMySQL:
+		return initMySQLDatabase(
+			endpoint,
+			username,
+			password,
+			dbName,
+			maxOpenConns,
+			maxIdleConns,
+			migrationFolder,
+			migrationVersion,
+		)
+	case store.DriverPostgres:
+		return initPostgresDatabase(
+			endpoint,
+			username,
+			password,
+			dbName,
+			sslMode,
+			maxOpenConns,
+			maxIdleConns,
+			migrationFolder,
+			migrationVersion,
+		)
+	case store.DriverSQLite:
+		return initSQLiteDatabase(
+			sqlitePath,
+			migrationFolder,
+			migrationVersion,
+		)
+	case store.DriverMemory:
+		store.Init(store.NewMemoryStore())
+		logger.New().Warn("JOBS ARE KEPT IN MEMORY ONLY")
+		return nil
+	default:
+		return fmt.Errorf("unknown database driver %s", driver)
+	}
+}
+
+func initMySQLDatabase(
 	endpoint string,
 	username string,
 	password string,
@@ -68,86 +170,171 @@ func initDatabase(
 		return err
 	}
 
-	migrationFolder = strings.TrimSuffix(migrationFolder, string(filepath.Separator))
-	logger.New().Debug("MIGRATION", zap.String("FOLDER", migrationFolder))
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationFolder),
+	err = migrateDatabase(driver, dbName, migrationFolder, migrationVersion)
+	if err != nil {
+		return err
+	}
+
+	// test MySQL connection
+	err = dbx.New().Ping()
+	if err != nil {
+		return err
+	}
+
+	store.Init(store.NewMySQLStore(instance))
+	return nil
+}
+
+func initPostgresDatabase(
+	endpoint string,
+	username string,
+	password string,
+	dbName string,
+	sslMode string,
+	maxOpenConns int,
+	maxIdleConns int,
+	migrationFolder string,
+	migrationVersion uint,
+) error {
+	instance, err := store.OpenPostgres(
+		endpoint,
+		username,
+		password,
 		dbName,
-		driver)
+		sslMode,
+		maxOpenConns,
+		maxIdleConns)
 
 	if err != nil {
 		return err
-	} else {
-		err := m.Migrate(migrationVersion)
-		if err != nil {
-			if err.Error() != "no change" {
-				logger.New().Info("MIGRATION", zap.String("RESULT", err.Error()))
-				return err
-			}
-		}
 	}
 
-	// test MySQL connection
-	return dbx.New().Ping()
+	driver, err := pgx.WithInstance(instance, &pgx.Config{})
+	if err != nil {
+		logger.New().Error(err.Error())
+		return err
+	}
+
+	err = migrateDatabase(driver, dbName, migrationFolder, migrationVersion)
+	if err != nil {
+		return err
+	}
+
+	store.Init(store.NewPostgresStore(instance))
+	return nil
 }
 
-func restoreScheduleJobs(scheduler quartz.Scheduler) error {
-	stmt1, err := dbx.New().Prepare(
-		`
-		SELECT * 
-		FROM schedule_jobs 
-		;
-	`)
+func initSQLiteDatabase(
+	path string,
+	migrationFolder string,
+	migrationVersion uint,
+) error {
+	instance, err := store.OpenSQLite(path)
 	if err != nil {
 		return err
 	}
-	defer stmt1.Close()
 
-	rows1, err := stmt1.Query()
+	driver, err := sqlite.WithInstance(instance, &sqlite.Config{})
 	if err != nil {
+		logger.New().Error(err.Error())
 		return err
 	}
-	defer rows1.Close()
 
-	scheduleJobs := []orm.ScheduleJob{}
-	err = scan.Rows(&scheduleJobs, rows1)
+	err = migrateDatabase(driver, path, migrationFolder, migrationVersion)
+	if err != nil {
+		return err
+	}
+
+	store.Init(store.NewSQLiteStore(instance))
+	return nil
+}
+
+// initSecrets loads the keys sealing secrets from a key file, or else from
+// keys given in the environment
+func initSecrets(keys string, keyFile string) error {
+	if keyFile != "" {
+		fileKeys, err := secret.LoadKeyFile(keyFile)
+		if err != nil {
+			return err
+		}
+
+		secret.Init(fileKeys)
+		return nil
+	}
+
+	if keys != "" {
+		envKeys, err := secret.ParseKeys(keys)
+		if err != nil {
+			return err
+		}
+
+		secret.Init(envKeys)
+	}
+
+	return nil
+}
+
+// initSecretProvider initializes the provider resolving secret references of
+// jobs
+func initSecretProvider(
+	name string,
+	envPrefix string,
+	directory string,
+	vaultAddress string,
+	vaultToken string,
+	vaultMount string,
+	vaultField string,
+) error {
+	switch name {
+	case secret.ProviderEnv:
+		secret.InitProvider(secret.NewEnvProvider(envPrefix))
+	case secret.ProviderFile:
+		secret.InitProvider(secret.NewFileProvider(directory))
+	case secret.ProviderVault:
+		if vaultAddress == "" {
+			return errors.New("vault address is required")
+		}
+		secret.InitProvider(secret.NewVaultProvider(vaultAddress, vaultToken, vaultMount, vaultField))
+	default:
+		return fmt.Errorf("unknown secret provider %s", name)
+	}
+
+	return nil
+}
+
+func restoreScheduleJobs(scheduler quartz.Scheduler) error {
+	scheduleJobs, _, err := store.New().List(&store.JobQuery{
+		Conditions: []store.JobCondition{
+			{Column: "Status", Operator: store.OperatorIn, Values: []interface{}{orm.JobStatusEnable}},
+		},
+	})
 	if err != nil {
 		return err
 	}
 
 	for _, scheduleJob := range scheduleJobs {
-		if scheduleJob.Status != orm.JobStatusEnable {
+		// fires up to now are left to the misfire policy
+		now := time.Now()
+		if err := helper.HandleMisfires(scheduleJob, now); err != nil {
+			logger.New().Error("FAILED TO HANDLE MISFIRES", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+		}
+
+		// a job may have nothing left to fire, such as an at job past due
+		if helper.JobExpired(&scheduleJob, now) {
+			if err := store.New().MarkDone(scheduleJob.JobID); err != nil {
+				logger.New().Error("FAILED TO UPDATE JOB STATUS", zap.String("JobID", scheduleJob.JobID), zap.String("Name", scheduleJob.Name), zap.Error(err))
+			}
 			continue
 		}
 
-		job, err := helper.NewJob(
-			scheduler,
-			scheduleJob.JobID,
-			scheduleJob.Name,
-			scheduleJob.TriggerType,
-			scheduleJob.Expression,
-			scheduleJob.HttpMethod,
-			scheduleJob.HttpTargetUrl,
-			scheduleJob.HttpRequestBody,
-			scheduleJob.JsonWebToken,
-		)
+		job, err := helper.NewJob(scheduler, scheduleJob)
 		if err != nil {
 			break
 		}
 
 		logger.New().Debug("SCHEDULE JOB WAS RESTORED", zap.String("JobID", scheduleJob.JobID), zap.Int("JobKey", job.Key()), zap.String("Name", scheduleJob.Name))
 
-		stmt2, err := dbx.New().Prepare(
-			`
-			UPDATE schedule_jobs SET JobKey=? WHERE JobID=?
-			;
-		`)
-		if err != nil {
-			return err
-		}
-		defer stmt2.Close()
-
-		_, err = stmt2.Exec(job.Key(), scheduleJob.JobID)
+		_, err = store.New().UpdateJobKey(&scheduleJob, job.Key())
 		if err != nil {
 			return err
 		}
@@ -156,6 +343,16 @@ func restoreScheduleJobs(scheduler quartz.Scheduler) error {
 	return err
 }
 
+// newNodeID identifies this replica in leader election
+func newNodeID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return utils.RandomUUIDString()
+	}
+
+	return hostname
+}
+
 func main() {
 	var (
 		showHelp    bool
@@ -194,23 +391,101 @@ func main() {
 	// fetch log configuration from environment variable
 	httpBindAddr := env.GetString("HTTP_BIND_ADDR", "0.0.0.0")
 	httpPort := env.GetInt("HTTP_PORT", 80)
+	dbDriver := env.GetString("DB_DRIVER", store.DriverMySQL)
 	dbEndpoint := env.GetString("DB_ENDPOINT", "")
 	dbName := env.GetString("DB_NAME", "SCHEDULER")
 	dbUsername := env.GetString("DB_USERNAME", "")
 	dbPassword := env.GetString("DB_PASSWORD", "")
 	dbMaxOpenConns := env.GetInt("DB_MAX_OPEN_CONNS", 25)
 	dbMaxIdleConns := env.GetInt("DB_MAX_IDLE_CONNS", 5)
-	dbMigrationsFolder := env.GetString("DB_MIGRATIONS_FOLDER", "/opt/db/migrations")
-	dbMigrationsVersion := env.GetUint("DB_MIGRATIONS_VERSION", 1)
+	dbSSLMode := env.GetString("DB_SSL_MODE", "prefer")
+	dbSQLitePath := env.GetString("DB_SQLITE_PATH", "/opt/db/scheduler.db")
+	dbMigrationsFolder := env.GetString("DB_MIGRATIONS_FOLDER", defaultMigrations[dbDriver].folder)
+	dbMigrationsVersion := env.GetUint("DB_MIGRATIONS_VERSION", defaultMigrations[dbDriver].version)
+	shutdownGraceSeconds := env.GetInt("SHUTDOWN_GRACE_SECONDS", 10)
+	haEnabled := env.GetBool("HA_ENABLED", false)
+	haNodeID := env.GetString("HA_NODE_ID", "")
+	haLeaseSeconds := env.GetInt("HA_LEASE_SECONDS", 15)
+	haReconcileSeconds := env.GetInt("HA_RECONCILE_SECONDS", 5)
+	fireLockEnabled := env.GetBool("FIRE_LOCK_ENABLED", false)
+	fireLockRetentionHours := env.GetInt("FIRE_LOCK_RETENTION_HOURS", 24)
+	secretKeys := env.GetString("SECRET_KEYS", "")
+	secretKeyFile := env.GetString("SECRET_KEY_FILE", "")
+	secretRedactionEnabled := env.GetBool("SECRET_REDACTION_ENABLED", true)
+	secretProvider := env.GetString("SECRET_PROVIDER", secret.ProviderEnv)
+	secretEnvPrefix := env.GetString("SECRET_ENV_PREFIX", "SCHEDULER_SECRET_")
+	secretDirectory := env.GetString("SECRET_DIRECTORY", "/var/run/secrets/scheduler")
+	secretVaultAddress := env.GetString("SECRET_VAULT_ADDR", "")
+	secretVaultToken := env.GetString("SECRET_VAULT_TOKEN", "")
+	secretVaultMount := env.GetString("SECRET_VAULT_MOUNT", "secret")
+	secretVaultField := env.GetString("SECRET_VAULT_FIELD", "value")
+	tlsProfilesFile := env.GetString("TLS_PROFILES_FILE", "")
+	hostRequestsPerSecond := env.GetFloat64("HOST_REQUESTS_PER_SECOND", 0)
+	hostBurst := env.GetInt("HOST_BURST", 1)
+	hostMaxInFlight := env.GetInt("HOST_MAX_IN_FLIGHT", 0)
+	hostLimitMaxWaitSeconds := env.GetInt("HOST_LIMIT_MAX_WAIT_SECONDS", int(helper.DefaultHostLimitMaxWait/time.Second))
+	hostLimitsRefreshSeconds := env.GetInt("HOST_LIMITS_REFRESH_SECONDS", 30)
+	httpMaxConnsPerHost := env.GetInt("HTTP_CLIENT_MAX_CONNS_PER_HOST", helper.DefaultMaxConnsPerHost)
+	httpMaxIdleConnsPerHost := env.GetInt("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", helper.DefaultMaxIdleConnsPerHost)
+	httpIdleConnTimeoutSeconds := env.GetInt("HTTP_CLIENT_IDLE_CONN_TIMEOUT_SECONDS", int(helper.DefaultIdleConnTimeout/time.Second))
+
+	// initialize keys sealing secrets, which have to be ready before jobs are
+	// read from the database
+	err = initSecrets(secretKeys, secretKeyFile)
+	if err != nil {
+		logger.New().Error("FAILED TO INITIALIZE SECRET KEYS", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// initialize the provider resolving secret references on each fire
+	err = initSecretProvider(
+		secretProvider,
+		secretEnvPrefix,
+		secretDirectory,
+		secretVaultAddress,
+		secretVaultToken,
+		secretVaultMount,
+		secretVaultField,
+	)
+	if err != nil {
+		logger.New().Error("FAILED TO INITIALIZE SECRET PROVIDER", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// load TLS profiles referenced by jobs, which have to be ready before jobs
+	// are restored
+	if tlsProfilesFile != "" {
+		err = helper.LoadTlsProfiles(tlsProfilesFile)
+		if err != nil {
+			logger.New().Error("FAILED TO LOAD TLS PROFILES", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.New().Info("TLS PROFILES LOADED", zap.Strings("Profiles", helper.TlsProfileNames()))
+	}
+
+	// share connections to targets across fires
+	helper.InitHttpClientPool(
+		httpMaxConnsPerHost,
+		httpMaxIdleConnsPerHost,
+		time.Duration(httpIdleConnTimeoutSeconds)*time.Second,
+	)
+
+	if !secretRedactionEnabled {
+		secret.DisableRedaction()
+		logger.New().Warn("SECRETS ARE RETURNED BY API")
+	}
 
 	// initialize database
 	err = initDatabase(
+		dbDriver,
 		dbEndpoint,
 		dbUsername,
 		dbPassword,
 		dbName,
+		dbSSLMode,
 		dbMaxOpenConns,
 		dbMaxIdleConns,
+		dbSQLitePath,
 		dbMigrationsFolder,
 		dbMigrationsVersion,
 	)
@@ -220,17 +495,110 @@ func main() {
 		os.Exit(1)
 	}
 
-	// initialize go-quartz
-	global.Scheduler = quartz.NewStdScheduler()
-	global.Scheduler.Start(context.Background())
+	if secret.Enabled() {
+		// seal secrets kept in plaintext or by rotated keys by the primary key
+		rotated, err := store.New().RotateSecrets()
+		if err != nil {
+			logger.New().Error("FAILED TO ROTATE SECRETS", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.New().Info("SECRETS SEALED", zap.String("KeyID", secret.PrimaryKeyID()), zap.Int("Rotated", rotated))
+	} else if dbDriver != store.DriverMemory {
+		logger.New().Warn("SECRETS ARE STORED IN PLAINTEXT")
+	}
+
+	// limit requests to each target host, by the stored limit of the host if
+	// any, or else by the default one
+	helper.InitHostLimits(orm.HostLimit{
+		RequestsPerSecond: hostRequestsPerSecond,
+		Burst:             hostBurst,
+		MaxInFlight:       hostMaxInFlight,
+	}, time.Duration(hostLimitMaxWaitSeconds)*time.Second)
 
-	// restore schedule jobs with SQLite3
-	err = restoreScheduleJobs(global.Scheduler)
+	err = helper.LoadHostLimits()
 	if err != nil {
-		logger.New().Error("FAILED TO RESTORE SCHEDULE JOB(S)", zap.Error(err))
+		logger.New().Error("FAILED TO LOAD HOST LIMITS", zap.Error(err))
 		os.Exit(1)
 	}
 
+	hostLimitsContext, hostLimitsCancel := context.WithCancel(context.Background())
+	if dbDriver != store.DriverMemory {
+		go helper.RefreshHostLimits(hostLimitsContext, time.Duration(hostLimitsRefreshSeconds)*time.Second)
+	}
+
+	// leases and fire claims are shared by replicas through MySQL
+	if (haEnabled || fireLockEnabled) && dbDriver != store.DriverMySQL {
+		logger.New().Error("HIGH AVAILABILITY MODE AND FIRE LOCK REQUIRE MYSQL", zap.String("Driver", dbDriver))
+		os.Exit(1)
+	}
+
+	// initialize go-quartz
+	scheduler := quartz.NewStdScheduler()
+	scheduler.Start(context.Background())
+
+	haContext, haCancel := context.WithCancel(context.Background())
+	haWaitGroup := sync.WaitGroup{}
+
+	if haNodeID == "" {
+		haNodeID = newNodeID()
+	}
+
+	if fireLockEnabled {
+		// every fire is claimed before being delivered
+		helper.EnableFireLock(haNodeID)
+
+		haWaitGroup.Add(1)
+		go func() {
+			defer haWaitGroup.Done()
+			helper.PurgeFireClaims(haContext, time.Duration(fireLockRetentionHours)*time.Hour)
+		}()
+		logger.New().Warn("FIRE LOCK ENABLED", zap.String("NodeID", haNodeID))
+	}
+
+	if haEnabled {
+		// only the elected leader schedules jobs, which are restored on election
+		election := helper.NewLeaderElection(haNodeID, time.Duration(haLeaseSeconds)*time.Second)
+		haScheduler := helper.NewHAScheduler(scheduler, election, restoreScheduleJobs)
+		global.Scheduler = haScheduler
+
+		haWaitGroup.Add(2)
+		go func() {
+			defer haWaitGroup.Done()
+			election.Run(haContext)
+		}()
+		go func() {
+			defer haWaitGroup.Done()
+			haScheduler.Reconcile(haContext, time.Duration(haReconcileSeconds)*time.Second)
+		}()
+		logger.New().Warn("HIGH AVAILABILITY MODE ENABLED", zap.String("NodeID", haNodeID))
+	} else if fireLockEnabled {
+		// every replica schedules every job, and keeps up with changes made
+		// through other replicas by reconciliation
+		haScheduler := helper.NewHAScheduler(scheduler, nil, restoreScheduleJobs)
+		global.Scheduler = haScheduler
+
+		err = restoreScheduleJobs(global.Scheduler)
+		if err != nil {
+			logger.New().Error("FAILED TO RESTORE SCHEDULE JOB(S)", zap.Error(err))
+			os.Exit(1)
+		}
+
+		haWaitGroup.Add(1)
+		go func() {
+			defer haWaitGroup.Done()
+			haScheduler.Reconcile(haContext, time.Duration(haReconcileSeconds)*time.Second)
+		}()
+	} else {
+		global.Scheduler = scheduler
+
+		// restore schedule jobs from the store
+		err = restoreScheduleJobs(global.Scheduler)
+		if err != nil {
+			logger.New().Error("FAILED TO RESTORE SCHEDULE JOB(S)", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+
 	// register os signal
 	interrupt = make(chan os.Signal, 1)
 	signal.Notify(interrupt, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
@@ -242,8 +610,21 @@ func main() {
 	httpServer.RegisterAPI("scheduler.v1.get.jobs", "GET", "/api/v1/jobs", v1.GetJobs)
 	httpServer.RegisterAPI("scheduler.v1.get.job", "GET", "/api/v1/jobs/{jobID}", v1.GetJob)
 	httpServer.RegisterAPI("scheduler.v1.put.job", "PUT", "/api/v1/jobs/{jobID}", v1.PutJob)
+	httpServer.RegisterAPI("scheduler.v1.patch.job", "PATCH", "/api/v1/jobs/{jobID}", v1.PatchJob)
 	httpServer.RegisterAPI("scheduler.v1.delete.job", "DELETE", "/api/v1/jobs/{jobID}", v1.DeleteJob)
 	httpServer.RegisterAPI("scheduler.v1.delete.jobs", "DELETE", "/api/v1/jobs", v1.DeleteJobs)
+	httpServer.RegisterAPI("scheduler.v1.run.job", "POST", "/api/v1/jobs/{jobID}:run", v1.RunJob)
+	httpServer.RegisterAPI("scheduler.v1.pause.job", "POST", "/api/v1/jobs/{jobID}:pause", v1.PauseJob)
+	httpServer.RegisterAPI("scheduler.v1.resume.job", "POST", "/api/v1/jobs/{jobID}:resume", v1.ResumeJob)
+	httpServer.RegisterAPI("scheduler.v1.get.job.next.runs", "GET", "/api/v1/jobs/{jobID}/next-runs", v1.GetJobNextRuns)
+	httpServer.RegisterAPI("scheduler.v1.preview.trigger", "POST", "/api/v1/triggers:preview", v1.PreviewTrigger)
+	httpServer.RegisterAPI("scheduler.v1.get.job.executions", "GET", "/api/v1/jobs/{jobID}/executions", v1.GetJobExecutions)
+	httpServer.RegisterAPI("scheduler.v1.get.job.execution", "GET", "/api/v1/jobs/{jobID}/executions/{executionID}", v1.GetJobExecution)
+	httpServer.RegisterAPI("scheduler.v1.get.job.execution.attempts", "GET", "/api/v1/jobs/{jobID}/executions/{executionID}/attempts", v1.GetJobExecutionAttempts)
+	httpServer.RegisterAPI("scheduler.v1.get.host.limits", "GET", "/api/v1/host-limits", v1.GetHostLimits)
+	httpServer.RegisterAPI("scheduler.v1.get.host.limit", "GET", "/api/v1/host-limits/{host}", v1.GetHostLimit)
+	httpServer.RegisterAPI("scheduler.v1.put.host.limit", "PUT", "/api/v1/host-limits/{host}", v1.PutHostLimit)
+	httpServer.RegisterAPI("scheduler.v1.delete.host.limit", "DELETE", "/api/v1/host-limits/{host}", v1.DeleteHostLimit)
 
 	// start HTTP server
 	httpServer.Start()
@@ -257,7 +638,15 @@ func main() {
 	httpServer.Stop()
 	logger.New().Info("HTTP SERVER EXITED")
 
+	// step down and release the lease, so that a follower takes over at once
+	haCancel()
+	haWaitGroup.Wait()
+	hostLimitsCancel()
+
 	global.Scheduler.Stop()
+
+	// give in-flight executions a grace period before canceling them
+	helper.Shutdown(time.Duration(shutdownGraceSeconds) * time.Second)
 	global.Scheduler.Wait(context.Background())
 	logger.New().Info("SCHEDULER EXITED")
 }
@@ -0,0 +1,9 @@
+package orm
+
+type HostLimit struct {
+	Host              string  `db:"Host"`
+	RequestsPerSecond float64 `db:"RequestsPerSecond"`
+	Burst             int     `db:"Burst"`
+	MaxInFlight       int     `db:"MaxInFlight"`
+	UpdateTime        int64   `db:"UpdateTime"`
+}
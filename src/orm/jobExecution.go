@@ -0,0 +1,41 @@
+package orm
+
+type JobExecution struct {
+	ExecutionID    string `db:"ExecutionID"`
+	JobID          string `db:"JobID"`
+	Status         int    `db:"Status"`
+	ScheduledTime  int64  `db:"ScheduledTime"`
+	StartTime      int64  `db:"StartTime"`
+	EndTime        int64  `db:"EndTime"`
+	HttpStatusCode int    `db:"HttpStatusCode"`
+	Latency        int64  `db:"Latency"`
+	Attempts       int    `db:"Attempts"`
+	ResponseBody   string `db:"ResponseBody"`
+	ErrorMessage   string `db:"ErrorMessage"`
+	TriggeredBy    string `db:"TriggeredBy"`
+}
+
+type JobExecutionAttempt struct {
+	ExecutionID    string `db:"ExecutionID"`
+	Attempt        int    `db:"Attempt"`
+	JobID          string `db:"JobID"`
+	StartTime      int64  `db:"StartTime"`
+	EndTime        int64  `db:"EndTime"`
+	HttpStatusCode int    `db:"HttpStatusCode"`
+	Latency        int64  `db:"Latency"`
+	ErrorMessage   string `db:"ErrorMessage"`
+}
+
+const (
+	ExecutionStatusSucceeded = 1
+	ExecutionStatusFailed    = 2
+	ExecutionStatusCancelled = 3
+	ExecutionStatusRunning   = 4
+)
+
+const (
+	ExecutionTriggeredBySchedule = "schedule"
+	ExecutionTriggeredByManual   = "manual"
+	ExecutionTriggeredByCatchUp  = "catchup"
+	ExecutionTriggeredByMisfire  = "misfire"
+)
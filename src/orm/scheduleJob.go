@@ -1,18 +1,46 @@
 package orm
 
 type ScheduleJob struct {
-	JobID           string `db:"JobID"`
-	JobKey          int    `db:"JobKey"`
-	Status          int    `db:"Status"`
-	Name            string `db:"Name"`
-	TriggerType     string `db:"TriggerType"`
-	Expression      string `db:"Expression"`
-	HttpMethod      string `db:"HttpMethod"`
-	HttpTargetUrl   string `db:"HttpTargetUrl"`
-	HttpRequestBody string `db:"HttpRequestBody"`
-	JsonWebToken    string `db:"JsonWebToken"`
-	CreationTime    int64  `db:"CreationTime"`
-	UpdateTime      int64  `db:"UpdateTime"`
+	JobID               string  `db:"JobID"`
+	JobKey              int     `db:"JobKey"`
+	Status              int     `db:"Status"`
+	Name                string  `db:"Name"`
+	TriggerType         string  `db:"TriggerType"`
+	Expression          string  `db:"Expression"`
+	TimeZone            string  `db:"TimeZone"`
+	HttpMethod          string  `db:"HttpMethod"`
+	HttpTargetUrl       string  `db:"HttpTargetUrl"`
+	HttpRequestBody     string  `db:"HttpRequestBody"`
+	HttpHeaders         string  `db:"HttpHeaders"`
+	ContentType         string  `db:"ContentType"`
+	JsonWebToken        string  `db:"JsonWebToken"`
+	SecretRef           string  `db:"SecretRef"`
+	AuthMode            string  `db:"AuthMode"`
+	OAuth2TokenUrl      string  `db:"OAuth2TokenUrl"`
+	OAuth2ClientID      string  `db:"OAuth2ClientID"`
+	OAuth2ClientSecret  string  `db:"OAuth2ClientSecret"`
+	OAuth2Scopes        string  `db:"OAuth2Scopes"`
+	SigningSecrets      string  `db:"SigningSecrets"`
+	TlsProfile          string  `db:"TlsProfile"`
+	JitterSeconds       int     `db:"JitterSeconds"`
+	JitterMode          string  `db:"JitterMode"`
+	TimeoutSeconds      int     `db:"TimeoutSeconds"`
+	RetryMaxAttempts    int     `db:"RetryMaxAttempts"`
+	RetryInitialBackoff int64   `db:"RetryInitialBackoff"`
+	RetryMultiplier     float64 `db:"RetryMultiplier"`
+	RetryMaxBackoff     int64   `db:"RetryMaxBackoff"`
+	RetryStatusCodes    string  `db:"RetryStatusCodes"`
+	MisfireAction       string  `db:"MisfireAction"`
+	MisfireMaxRuns      int     `db:"MisfireMaxRuns"`
+	StartAt             int64   `db:"StartAt"`
+	EndAt               int64   `db:"EndAt"`
+	MaxRuns             int     `db:"MaxRuns"`
+	PausedTime          int64   `db:"PausedTime"`
+	Version             int     `db:"Version"`
+	LastFireTime        int64   `db:"LastFireTime"`
+	RunCount            int     `db:"RunCount"`
+	CreationTime        int64   `db:"CreationTime"`
+	UpdateTime          int64   `db:"UpdateTime"`
 }
 
 const (
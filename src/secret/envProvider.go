@@ -0,0 +1,31 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+type envProvider struct {
+	prefix string
+}
+
+// NewEnvProvider resolves secrets from environment variables named by prefix
+// followed by the upper-cased name with dots and dashes turned into
+// underscores, e.g. billing-api-token from SCHEDULER_SECRET_BILLING_API_TOKEN
+func NewEnvProvider(prefix string) Provider {
+	return &envProvider{
+		prefix: prefix,
+	}
+}
+
+func (provider *envProvider) Resolve(ctx context.Context, name string) (string, error) {
+	key := provider.prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+
+	return value, nil
+}
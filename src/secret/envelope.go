@@ -0,0 +1,142 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// prefix of sealed secrets, which are followed by the ID of the key wrapping
+// the data key, the wrapped data key and the ciphertext, separated by colon
+const sealedPrefix = "enc:v1:"
+
+var ErrMalformedSecret = errors.New("malformed sealed secret")
+
+// encrypt encrypts plaintext by AES-GCM, prepending the random nonce
+func encrypt(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// decrypt decrypts what encrypt returns
+func decrypt(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrMalformedSecret
+	}
+
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], additionalData)
+}
+
+// Seal encrypts a secret by a random data key, which is in turn encrypted by
+// the primary key. Empty secrets, and every secret while no keys are given,
+// are returned as is.
+func Seal(plaintext string) (string, error) {
+	if plaintext == "" || !Enabled() {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, keySize)
+	_, err := rand.Read(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encrypt(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := encrypt(keys[0].material, dataKey, []byte(keys[0].ID))
+	if err != nil {
+		return "", err
+	}
+
+	return sealedPrefix + strings.Join([]string{
+		keys[0].ID,
+		base64.StdEncoding.EncodeToString(wrappedKey),
+		base64.StdEncoding.EncodeToString(ciphertext),
+	}, ":"), nil
+}
+
+// IsSealed tells whether a stored secret was returned by Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+// Open decrypts a secret returned by Seal by whichever key sealed it. Secrets
+// stored in plaintext are returned as is.
+func Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(value, sealedPrefix), ":")
+	if len(tokens) != 3 {
+		return "", ErrMalformedSecret
+	}
+
+	key, err := findKey(tokens[0])
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(tokens[1])
+	if err != nil {
+		return "", ErrMalformedSecret
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(tokens[2])
+	if err != nil {
+		return "", ErrMalformedSecret
+	}
+
+	dataKey, err := decrypt(key.material, wrappedKey, []byte(key.ID))
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := decrypt(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Stale tells whether a stored secret is due to be sealed again by the
+// primary key, being either in plaintext or sealed by a rotated key
+func Stale(value string) bool {
+	if value == "" || !Enabled() {
+		return false
+	}
+
+	return !strings.HasPrefix(value, sealedPrefix+keys[0].ID+":")
+}
@@ -0,0 +1,36 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type fileProvider struct {
+	directory string
+}
+
+// NewFileProvider resolves secrets from the files named after them in a
+// directory, such as a mounted Kubernetes secret, so that the files updated
+// in place take effect on the next use
+func NewFileProvider(directory string) Provider {
+	return &fileProvider{
+		directory: directory,
+	}
+}
+
+func (provider *fileProvider) Resolve(ctx context.Context, name string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(provider.directory, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// files are often written with a trailing line break
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
@@ -0,0 +1,112 @@
+package secret
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// size of keys in byte, selecting AES-256
+const keySize = 32
+
+var (
+	ErrNoKeys     = errors.New("no secret keys")
+	ErrUnknownKey = errors.New("unknown secret key")
+)
+
+// Key encrypts the data keys of secrets
+type Key struct {
+	ID       string
+	material []byte
+}
+
+// keys in use, the first one sealing new secrets and the rest only opening
+// the ones sealed before rotation
+var keys []Key = nil
+
+// ParseKeys parses keys given as "id:base64" entries separated by comma or
+// line break. Blank entries and lines starting with # are skipped.
+func ParseKeys(value string) ([]Key, error) {
+	parsedKeys := []Key{}
+	for _, line := range strings.Split(value, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+
+			tokens := strings.SplitN(entry, ":", 2)
+			if len(tokens) != 2 || tokens[0] == "" {
+				return nil, errors.New("secret key must be given as id:base64")
+			}
+
+			material, err := base64.StdEncoding.DecodeString(tokens[1])
+			if err != nil {
+				return nil, fmt.Errorf("secret key %s: %w", tokens[0], err)
+			}
+			if len(material) != keySize {
+				return nil, fmt.Errorf("secret key %s must be %d bytes", tokens[0], keySize)
+			}
+
+			for _, key := range parsedKeys {
+				if key.ID == tokens[0] {
+					return nil, fmt.Errorf("duplicate secret key %s", tokens[0])
+				}
+			}
+
+			parsedKeys = append(parsedKeys, Key{ID: tokens[0], material: material})
+		}
+	}
+
+	if len(parsedKeys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	return parsedKeys, nil
+}
+
+// LoadKeyFile parses the keys kept in a file, one entry per line
+func LoadKeyFile(path string) ([]Key, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeys(string(content))
+}
+
+// Init makes keys the ones sealing and opening secrets, the first one being
+// primary
+func Init(initKeys []Key) {
+	keys = initKeys
+}
+
+// Enabled tells whether secrets are sealed, or else kept in plaintext
+func Enabled() bool {
+	return len(keys) != 0
+}
+
+// PrimaryKeyID returns the ID of the key sealing new secrets
+func PrimaryKeyID() string {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	return keys[0].ID
+}
+
+func findKey(keyID string) (*Key, error) {
+	for i := range keys {
+		if keys[i].ID == keyID {
+			return &keys[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w %s", ErrUnknownKey, keyID)
+}
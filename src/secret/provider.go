@@ -0,0 +1,64 @@
+package secret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// providers of named secrets, selected by SECRET_PROVIDER
+const (
+	ProviderEnv   = "env"
+	ProviderFile  = "file"
+	ProviderVault = "vault"
+)
+
+var (
+	ErrNoProvider       = errors.New("no secret provider")
+	ErrSecretNotFound   = errors.New("secret not found")
+	ErrInvalidSecretRef = errors.New("invalid secret reference")
+)
+
+// max length of secret references
+const maxSecretRefLength = 128
+
+// names of secrets, which never reach out of the directory of the file
+// provider
+var secretRefPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidSecretRef tells whether name is able to reference a secret
+func ValidSecretRef(name string) bool {
+	return len(name) <= maxSecretRefLength && secretRefPattern.MatchString(name)
+}
+
+// Provider resolves secrets referenced by name, whose values are expected to
+// change without notice, so that they are resolved on every use
+type Provider interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+var provider Provider = nil
+
+// InitProvider makes provider the one resolving secret references
+func InitProvider(initProvider Provider) {
+	provider = initProvider
+}
+
+// Resolve resolves a secret reference by the provider in use
+func Resolve(ctx context.Context, name string) (string, error) {
+	if !ValidSecretRef(name) {
+		return "", fmt.Errorf("%w %q", ErrInvalidSecretRef, name)
+	}
+
+	if provider == nil {
+		return "", ErrNoProvider
+	}
+
+	value, err := provider.Resolve(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("secret %s: %w", name, err)
+	}
+
+	return value, nil
+}
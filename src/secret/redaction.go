@@ -0,0 +1,30 @@
+package secret
+
+// placeholder of secrets in API responses and logs
+const Redacted = "******"
+
+var redactionDisabled = false
+
+// DisableRedaction makes Redact return secrets as is
+func DisableRedaction() {
+	redactionDisabled = true
+}
+
+// Mask hides a secret from logs, leaving it empty if it is unset so that
+// whether it is set still shows
+func Mask(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return Redacted
+}
+
+// Redact hides a secret from API responses unless redaction is disabled
+func Redact(value string) string {
+	if redactionDisabled {
+		return value
+	}
+
+	return Mask(value)
+}
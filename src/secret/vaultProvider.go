@@ -0,0 +1,79 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// timeout of reading a secret from Vault
+const vaultTimeout = 10 * time.Second
+
+type vaultProvider struct {
+	address string
+	token   string
+	mount   string
+	field   string
+	client  *http.Client
+}
+
+// NewVaultProvider resolves secrets from the KV version 2 secrets engine
+// mounted at mount of a Vault-compatible server, reading field of the latest
+// version of the secret at the path of the name
+func NewVaultProvider(address string, token string, mount string, field string) Provider {
+	return &vaultProvider{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		field:   field,
+		client:  &http.Client{Timeout: vaultTimeout},
+	}
+}
+
+func (provider *vaultProvider) Resolve(ctx context.Context, name string) (string, error) {
+	request, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodGet,
+		provider.address+"/v1/"+provider.mount+"/data/"+url.PathEscape(name),
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-Vault-Token", provider.token)
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return "", ErrSecretNotFound
+	}
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault responded %s", response.Status)
+	}
+
+	body := struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}{}
+	err = json.NewDecoder(io.LimitReader(response.Body, 1<<20)).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := body.Data.Data[provider.field].(string)
+	if !ok {
+		return "", fmt.Errorf("%w: no field %s", ErrSecretNotFound, provider.field)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,138 @@
+// Package signature signs webhook requests of the scheduler and verifies them
+// on behalf of receivers.
+//
+// A signed request carries the time it was signed at in the
+// X-Scheduler-Timestamp header (epoch in second), and one or more signatures
+// in the X-Scheduler-Signature header, e.g. "v1=5257a869...,v1=6ffbb59b...".
+// Every signature is the hex-encoded HMAC-SHA256, keyed by one of the signing
+// secrets of the job, of the method, the escaped path, the timestamp and the
+// body joined by line feed. Jobs in the middle of a rotation are signed by
+// every active secret, so that receivers accept requests by either the old or
+// the new secret.
+package signature
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TimestampHeader = "X-Scheduler-Timestamp"
+	SignatureHeader = "X-Scheduler-Signature"
+	// scheme of signatures, prefixing each of them as "v1="
+	Scheme = "v1"
+	// difference between the timestamp of a request and the time it is
+	// verified at which is tolerated by default, rejecting replays afterwards
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	ErrNoSignature       = errors.New("request is not signed")
+	ErrInvalidTimestamp  = errors.New("invalid signature timestamp")
+	ErrTimestampExpired  = errors.New("signature timestamp is out of tolerance")
+	ErrSignatureMismatch = errors.New("no signature matches")
+)
+
+// Sign returns the signature of a request by a secret
+func Sign(secret string, method string, path string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + path + "\n" + strconv.FormatInt(timestamp, 10) + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaderValue returns the value of the signature header of a request
+// signed by every secret
+func SignatureHeaderValue(secrets []string, method string, path string, timestamp int64, body []byte) string {
+	signatures := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		signatures = append(signatures, Scheme+"="+Sign(secret, method, path, timestamp, body))
+	}
+
+	return strings.Join(signatures, ",")
+}
+
+// Verify checks the timestamp and signature headers of a request against the
+// secrets known by the receiver, any of which matching any of the signatures
+// is enough
+func Verify(
+	secrets []string,
+	method string,
+	path string,
+	timestampHeader string,
+	signatureHeader string,
+	body []byte,
+	tolerance time.Duration,
+	now time.Time,
+) error {
+	if timestampHeader == "" || signatureHeader == "" {
+		return ErrNoSignature
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	difference := now.Sub(time.Unix(timestamp, 0))
+	if difference > tolerance || difference < -tolerance {
+		return ErrTimestampExpired
+	}
+
+	for _, secret := range secrets {
+		expected, _ := hex.DecodeString(Sign(secret, method, path, timestamp, body))
+		for _, token := range strings.Split(signatureHeader, ",") {
+			scheme, value, ok := strings.Cut(strings.TrimSpace(token), "=")
+			if !ok || scheme != Scheme {
+				continue
+			}
+
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				continue
+			}
+
+			if hmac.Equal(signature, expected) {
+				return nil
+			}
+		}
+	}
+
+	return ErrSignatureMismatch
+}
+
+// VerifyRequest verifies a request received by an HTTP server within the
+// default tolerance. The body is read and put back, so that handlers are able
+// to read it again.
+func VerifyRequest(r *http.Request, secrets []string) error {
+	body := []byte{}
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return Verify(
+		secrets,
+		r.Method,
+		r.URL.EscapedPath(),
+		r.Header.Get(TimestampHeader),
+		r.Header.Get(SignatureHeader),
+		body,
+		DefaultTolerance,
+		time.Now(),
+	)
+}
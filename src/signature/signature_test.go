@@ -0,0 +1,310 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	oldSecret = "old-secret-0123456789"
+	newSecret = "new-secret-0123456789"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"ping":true}`)
+
+	// the canonical string is the method, the escaped path, the timestamp and
+	// the body joined by line feed
+	mac := hmac.New(sha256.New, []byte(newSecret))
+	mac.Write([]byte("POST\n/hooks/a%20b\n1700000000\n" + string(body)))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if signature := Sign(newSecret, "post", "/hooks/a%20b", 1700000000, body); signature != expected {
+		t.Errorf("got signature %s, want %s", signature, expected)
+	}
+
+	if Sign(oldSecret, "POST", "/hooks/a%20b", 1700000000, body) == expected {
+		t.Error("signatures by different secrets match")
+	}
+}
+
+func TestSignatureHeaderValue(t *testing.T) {
+	value := SignatureHeaderValue([]string{oldSecret, newSecret}, "GET", "/", 1700000000, nil)
+
+	expected := "v1=" + Sign(oldSecret, "GET", "/", 1700000000, nil) + ",v1=" + Sign(newSecret, "GET", "/", 1700000000, nil)
+	if value != expected {
+		t.Errorf("got %s, want %s", value, expected)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	timestamp := now.Unix()
+	body := []byte(`{"ping":true}`)
+	signedBy := func(secrets ...string) string {
+		return SignatureHeaderValue(secrets, "POST", "/hooks/a", timestamp, body)
+	}
+
+	tests := []struct {
+		name            string
+		secrets         []string
+		method          string
+		path            string
+		timestampHeader string
+		signatureHeader string
+		body            []byte
+		elapsed         time.Duration
+		want            error
+	}{
+		{
+			name:            "valid",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+		},
+		{
+			name:            "method in lower case",
+			secrets:         []string{newSecret},
+			method:          "post",
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+		},
+		{
+			name:            "tampered body",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+			body:            []byte(`{"ping":false}`),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "tampered path",
+			secrets:         []string{newSecret},
+			path:            "/hooks/b",
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "tampered timestamp",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp+1, 10),
+			signatureHeader: signedBy(newSecret),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "timestamp at tolerance",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+			elapsed:         DefaultTolerance,
+		},
+		{
+			name:            "timestamp too old",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp-int64(DefaultTolerance/time.Second)-1, 10),
+			signatureHeader: signedBy(newSecret),
+			want:            ErrTimestampExpired,
+		},
+		{
+			name:            "timestamp too far ahead",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp+int64(DefaultTolerance/time.Second)+1, 10),
+			signatureHeader: signedBy(newSecret),
+			want:            ErrTimestampExpired,
+		},
+		{
+			name:            "rotation known by new secret",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(oldSecret, newSecret),
+		},
+		{
+			name:            "rotation known by old secret",
+			secrets:         []string{oldSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(oldSecret, newSecret),
+		},
+		{
+			name:            "receiver rotating",
+			secrets:         []string{"unknown-secret-0123456789", newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+		},
+		{
+			name:            "unknown secret",
+			secrets:         []string{oldSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "spaces around signatures",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: " " + strings.ReplaceAll(signedBy(oldSecret, newSecret), ",", " , ") + " ",
+		},
+		{
+			name:            "missing timestamp",
+			secrets:         []string{newSecret},
+			signatureHeader: signedBy(newSecret),
+			want:            ErrNoSignature,
+		},
+		{
+			name:            "missing signature",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			want:            ErrNoSignature,
+		},
+		{
+			name:            "malformed timestamp",
+			secrets:         []string{newSecret},
+			timestampHeader: "yesterday",
+			signatureHeader: signedBy(newSecret),
+			want:            ErrInvalidTimestamp,
+		},
+		{
+			name:            "signature without scheme",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: Sign(newSecret, "POST", "/hooks/a", timestamp, body),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "signature of unknown scheme",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: "v0=" + Sign(newSecret, "POST", "/hooks/a", timestamp, body),
+			want:            ErrSignatureMismatch,
+		},
+		{
+			name:            "signature not in hex",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: "v1=not-hex," + signedBy(newSecret),
+		},
+		{
+			name:            "truncated signature",
+			secrets:         []string{newSecret},
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+			signatureHeader: signedBy(newSecret)[:40],
+			want:            ErrSignatureMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = "POST"
+			}
+			path := test.path
+			if path == "" {
+				path = "/hooks/a"
+			}
+			requestBody := test.body
+			if requestBody == nil {
+				requestBody = body
+			}
+
+			err := Verify(test.secrets, method, path, test.timestampHeader, test.signatureHeader, requestBody, DefaultTolerance, now.Add(test.elapsed))
+			if !errors.Is(err, test.want) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := `{"ping":true}`
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		sign   func(r *http.Request, timestamp int64)
+		want   error
+	}{
+		{
+			name:   "valid",
+			method: http.MethodPost,
+			target: "/hooks/a%20b?x=1",
+			body:   body,
+			sign: func(r *http.Request, timestamp int64) {
+				r.Header.Set(SignatureHeader, SignatureHeaderValue([]string{newSecret}, "POST", "/hooks/a%20b", timestamp, []byte(body)))
+			},
+		},
+		{
+			name:   "valid without body",
+			method: http.MethodGet,
+			target: "/hooks/a",
+			sign: func(r *http.Request, timestamp int64) {
+				r.Header.Set(SignatureHeader, SignatureHeaderValue([]string{newSecret}, "GET", "/hooks/a", timestamp, nil))
+			},
+		},
+		{
+			name:   "tampered body",
+			method: http.MethodPost,
+			target: "/hooks/a",
+			body:   `{"ping":false}`,
+			sign: func(r *http.Request, timestamp int64) {
+				r.Header.Set(SignatureHeader, SignatureHeaderValue([]string{newSecret}, "POST", "/hooks/a", timestamp, []byte(body)))
+			},
+			want: ErrSignatureMismatch,
+		},
+		{
+			name:   "tampered path",
+			method: http.MethodPost,
+			target: "/hooks/b",
+			body:   body,
+			sign: func(r *http.Request, timestamp int64) {
+				r.Header.Set(SignatureHeader, SignatureHeaderValue([]string{newSecret}, "POST", "/hooks/a", timestamp, []byte(body)))
+			},
+			want: ErrSignatureMismatch,
+		},
+		{
+			name:   "unsigned",
+			method: http.MethodPost,
+			target: "/hooks/a",
+			body:   body,
+			sign:   func(r *http.Request, timestamp int64) {},
+			want:   ErrNoSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+
+			timestamp := time.Now().Unix()
+			test.sign(r, timestamp)
+			if r.Header.Get(SignatureHeader) != "" {
+				r.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+			}
+
+			err := VerifyRequest(r, []string{newSecret})
+			if !errors.Is(err, test.want) {
+				t.Fatalf("got %v, want %v", err, test.want)
+			}
+
+			// handlers still read the body after verification
+			readBody, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(readBody) != test.body {
+				t.Errorf("got body %q, want %q", readBody, test.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"errors"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+// backends of the store, selected by DB_DRIVER
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+var (
+	ErrJobNotFound        = errors.New("job not found")
+	ErrExecutionNotFound  = errors.New("execution not found")
+	ErrJobVersionConflict = errors.New("job has been modified")
+	ErrHostLimitNotFound  = errors.New("host limit not found")
+)
+
+// operators of job conditions
+const (
+	// the column equals any of the values
+	OperatorIn = "IN"
+	// the column is no less than the value
+	OperatorFrom = ">="
+	// the column is no greater than the value
+	OperatorTo = "<="
+	// the column starts with the value, case-insensitively
+	OperatorPrefix = "PREFIX"
+)
+
+// JobCondition restricts the jobs being listed by a column of schedule_jobs
+type JobCondition struct {
+	Column   string
+	Operator string
+	Values   []interface{}
+}
+
+// JobOrder sorts the jobs being listed by a column of schedule_jobs
+type JobOrder struct {
+	Column     string
+	Descending bool
+}
+
+// JobQuery selects a page of jobs. Conditions are AND-ed, and Search matches
+// the name or target URL of jobs case-insensitively. A zero Size returns every
+// job from From.
+type JobQuery struct {
+	Conditions []JobCondition
+	Search     string
+	Orders     []JobOrder
+	From       int
+	Size       int
+}
+
+// JobStore persists schedule jobs, sealing their secrets by the keys of the
+// secret package wherever they are kept at rest. Updates of the settings and the state of a
+// job only take effect while the stored version is the one preceding the
+// version carried by the job, and fail with ErrJobVersionConflict otherwise.
+type JobStore interface {
+	Create(scheduleJob *orm.ScheduleJob) error
+	Get(jobID string) (*orm.ScheduleJob, error)
+	List(query *JobQuery) ([]orm.ScheduleJob, int, error)
+	Update(scheduleJob *orm.ScheduleJob) error
+	// UpdateState persists JobKey, Status, PausedTime and UpdateTime only
+	UpdateState(scheduleJob *orm.ScheduleJob) error
+	// Delete deletes a job along with its execution history
+	Delete(jobID string) error
+	DeleteAll() error
+	// MarkDone marks a job which has nothing left to fire as done
+	MarkDone(jobID string) error
+	// UpdateJobKey records the key of a scheduled job unless the job has been
+	// modified since it was read, and reports whether it did
+	UpdateJobKey(scheduleJob *orm.ScheduleJob, jobKey int) (bool, error)
+	// CountRun counts a run of a job, and reports whether the job had a run
+	// left within its max runs
+	CountRun(jobID string) (bool, error)
+	// RecordFire keeps the scheduled time (epoch in millisecond) of the latest
+	// fire delivered by a job
+	RecordFire(jobID string, scheduledTime int64) error
+	// RotateSecrets seals the secrets of jobs again by the primary key when
+	// they are kept in plaintext or sealed by a rotated key, and returns the
+	// number of secrets rotated
+	RotateSecrets() (int, error)
+}
+
+// ExecutionStore persists the execution history of jobs
+type ExecutionStore interface {
+	CreateExecution(execution *orm.JobExecution) error
+	UpdateExecution(execution *orm.JobExecution) error
+	CreateExecutionAttempt(executionAttempt *orm.JobExecutionAttempt) error
+	// ListExecutions returns a page of executions of a job, latest first,
+	// along with the total count of them
+	ListExecutions(jobID string, from int, size int) ([]orm.JobExecution, int, error)
+	GetExecution(jobID string, executionID string) (*orm.JobExecution, error)
+	ListExecutionAttempts(jobID string, executionID string) ([]orm.JobExecutionAttempt, error)
+}
+
+// HostLimitStore persists the limits of requests to target hosts, keyed by
+// host in lower case
+type HostLimitStore interface {
+	ListHostLimits() ([]orm.HostLimit, error)
+	GetHostLimit(host string) (*orm.HostLimit, error)
+	// PutHostLimit creates or replaces the limit of a host
+	PutHostLimit(hostLimit *orm.HostLimit) error
+	DeleteHostLimit(host string) error
+}
+
+type Store interface {
+	JobStore
+	ExecutionStore
+	HostLimitStore
+}
+
+var instance Store = nil
+
+// Init makes store the one returned by New
+func Init(store Store) {
+	instance = store
+}
+
+func New() Store {
+	return instance
+}
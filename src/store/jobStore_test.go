@@ -0,0 +1,591 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+// migrations of the SQLite store, relative to the package directory
+const sqliteMigrations = "../../db/migrations-sqlite"
+
+// newSQLiteTestStore opens an SQLite store on a temporary file, migrated to
+// the latest version
+func newSQLiteTestStore(t *testing.T) Store {
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "scheduler.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob(filepath.Join(sqliteMigrations, "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("no migrations in %s", sqliteMigrations)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(file), err)
+		}
+	}
+
+	return NewSQLiteStore(db)
+}
+
+// forEachStore runs test against a fresh store of every backend, which are
+// expected to behave the same
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStore())
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, newSQLiteTestStore(t))
+	})
+}
+
+func newTestJob(jobID string) *orm.ScheduleJob {
+	return &orm.ScheduleJob{
+		JobID:          jobID,
+		JobKey:         1,
+		Status:         orm.JobStatusEnable,
+		Name:           jobID,
+		TriggerType:    "interval",
+		Expression:     "60",
+		TimeZone:       "UTC",
+		HttpMethod:     "POST",
+		HttpTargetUrl:  "http://localhost/" + jobID,
+		ContentType:    "application/json",
+		TimeoutSeconds: 30,
+		MisfireAction:  "skip",
+		Version:        1,
+		CreationTime:   1700000000,
+		UpdateTime:     1700000000,
+	}
+}
+
+func createTestJob(t *testing.T, store Store, scheduleJob *orm.ScheduleJob) {
+	if err := store.Create(scheduleJob); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getTestJob(t *testing.T, store Store, jobID string) *orm.ScheduleJob {
+	scheduleJob, err := store.Get(jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return scheduleJob
+}
+
+func TestCreate(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		scheduleJob := newTestJob("job-1")
+		scheduleJob.HttpHeaders = `{"X-Tenant":"a"}`
+		scheduleJob.JsonWebToken = "token"
+		scheduleJob.MaxRuns = 3
+		// columns left to their defaults on insert
+		scheduleJob.RunCount = 2
+		scheduleJob.LastFireTime = 1700000000000
+		scheduleJob.PausedTime = 1700000000
+		createTestJob(t, store, scheduleJob)
+
+		expected := *scheduleJob
+		expected.RunCount = 0
+		expected.LastFireTime = 0
+		expected.PausedTime = 0
+		if stored := getTestJob(t, store, "job-1"); !reflect.DeepEqual(*stored, expected) {
+			t.Errorf("got %+v, want %+v", *stored, expected)
+		}
+
+		if err := store.Create(newTestJob("job-1")); err == nil {
+			t.Error("created a duplicate job")
+		}
+
+		if _, err := store.Get("job-2"); !errors.Is(err, ErrJobNotFound) {
+			t.Errorf("got %v, want %v", err, ErrJobNotFound)
+		}
+	})
+}
+
+func TestCountRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxRuns  int
+		runs     int
+		expected []bool
+	}{
+		{name: "unlimited", maxRuns: 0, runs: 3, expected: []bool{true, true, true}},
+		{name: "limited", maxRuns: 2, runs: 3, expected: []bool{true, true, false}},
+		{name: "single", maxRuns: 1, runs: 2, expected: []bool{true, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, store Store) {
+				scheduleJob := newTestJob("job-1")
+				scheduleJob.MaxRuns = test.maxRuns
+				createTestJob(t, store, scheduleJob)
+
+				counted := []bool{}
+				for i := 0; i < test.runs; i++ {
+					ok, err := store.CountRun("job-1")
+					if err != nil {
+						t.Fatal(err)
+					}
+					counted = append(counted, ok)
+				}
+
+				if !reflect.DeepEqual(counted, test.expected) {
+					t.Errorf("got %v, want %v", counted, test.expected)
+				}
+
+				runCount := 0
+				for _, ok := range test.expected {
+					if ok {
+						runCount++
+					}
+				}
+				if stored := getTestJob(t, store, "job-1"); stored.RunCount != runCount {
+					t.Errorf("got run count %d, want %d", stored.RunCount, runCount)
+				}
+			})
+		})
+	}
+
+	forEachStore(t, func(t *testing.T, store Store) {
+		if ok, err := store.CountRun("job-1"); err != nil || ok {
+			t.Errorf("got %v, %v counting a run of a missing job", ok, err)
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		jobID   string
+		version int
+		want    error
+	}{
+		{name: "next version", jobID: "job-1", version: 3},
+		{name: "stale version", jobID: "job-1", version: 2, want: ErrJobVersionConflict},
+		{name: "version ahead", jobID: "job-1", version: 4, want: ErrJobVersionConflict},
+		{name: "missing job", jobID: "job-2", version: 3, want: ErrJobVersionConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, store Store) {
+				scheduleJob := newTestJob("job-1")
+				scheduleJob.Version = 2
+				createTestJob(t, store, scheduleJob)
+				if _, err := store.CountRun("job-1"); err != nil {
+					t.Fatal(err)
+				}
+				if err := store.RecordFire("job-1", 1700000060000); err != nil {
+					t.Fatal(err)
+				}
+
+				updated := newTestJob(test.jobID)
+				updated.Name = "updated"
+				updated.JobKey = 2
+				updated.Status = orm.JobStatusDisable
+				updated.PausedTime = 1700000100
+				updated.UpdateTime = 1700000100
+				updated.Version = test.version
+				err := store.Update(updated)
+				if !errors.Is(err, test.want) {
+					t.Fatalf("got %v, want %v", err, test.want)
+				}
+
+				stored := getTestJob(t, store, "job-1")
+				if test.want != nil {
+					if stored.Name != "job-1" || stored.Version != 2 {
+						t.Errorf("got %s of version %d, want job-1 of version 2", stored.Name, stored.Version)
+					}
+					return
+				}
+
+				// the run count and the last fire time are kept by the job
+				expected := *updated
+				expected.RunCount = 1
+				expected.LastFireTime = 1700000060000
+				if !reflect.DeepEqual(*stored, expected) {
+					t.Errorf("got %+v, want %+v", *stored, expected)
+				}
+			})
+		})
+	}
+}
+
+func TestUpdateState(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		want    error
+	}{
+		{name: "next version", version: 2},
+		{name: "stale version", version: 1, want: ErrJobVersionConflict},
+		{name: "version ahead", version: 3, want: ErrJobVersionConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, store Store) {
+				createTestJob(t, store, newTestJob("job-1"))
+
+				updated := newTestJob("job-1")
+				updated.Name = "updated"
+				updated.JobKey = 2
+				updated.Status = orm.JobStatusDisable
+				updated.PausedTime = 1700000100
+				updated.UpdateTime = 1700000100
+				updated.Version = test.version
+				err := store.UpdateState(updated)
+				if !errors.Is(err, test.want) {
+					t.Fatalf("got %v, want %v", err, test.want)
+				}
+
+				expected := *newTestJob("job-1")
+				if test.want == nil {
+					// the state is updated, leaving the settings as is
+					expected.JobKey = 2
+					expected.Status = orm.JobStatusDisable
+					expected.PausedTime = 1700000100
+					expected.UpdateTime = 1700000100
+					expected.Version = 2
+				}
+				if stored := getTestJob(t, store, "job-1"); !reflect.DeepEqual(*stored, expected) {
+					t.Errorf("got %+v, want %+v", *stored, expected)
+				}
+			})
+		})
+	}
+}
+
+func TestMarkDone(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		createTestJob(t, store, newTestJob("job-1"))
+
+		// marking a job done twice bumps its version once
+		for i := 0; i < 2; i++ {
+			if err := store.MarkDone("job-1"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stored := getTestJob(t, store, "job-1")
+		if stored.Status != orm.JobStatusDone || stored.Version != 2 {
+			t.Errorf("got status %d of version %d, want %d of version 2", stored.Status, stored.Version, orm.JobStatusDone)
+		}
+
+		// updates made on the version read before are rejected
+		updated := newTestJob("job-1")
+		updated.Version = 2
+		if err := store.Update(updated); !errors.Is(err, ErrJobVersionConflict) {
+			t.Errorf("got %v, want %v", err, ErrJobVersionConflict)
+		}
+
+		if err := store.MarkDone("job-2"); err != nil {
+			t.Errorf("got %v marking a missing job done", err)
+		}
+	})
+}
+
+func TestUpdateJobKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  int
+		expected bool
+	}{
+		{name: "current version", version: 1, expected: true},
+		{name: "modified since read", version: 2, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, store Store) {
+				createTestJob(t, store, newTestJob("job-1"))
+
+				read := newTestJob("job-1")
+				read.Version = test.version
+				updated, err := store.UpdateJobKey(read, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if updated != test.expected {
+					t.Errorf("got %v, want %v", updated, test.expected)
+				}
+
+				jobKey := 1
+				if test.expected {
+					jobKey = 2
+				}
+				if stored := getTestJob(t, store, "job-1"); stored.JobKey != jobKey || stored.Version != 1 {
+					t.Errorf("got key %d of version %d, want %d of version 1", stored.JobKey, stored.Version, jobKey)
+				}
+			})
+		})
+	}
+}
+
+func TestRecordFire(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		createTestJob(t, store, newTestJob("job-1"))
+
+		// fires delivered out of order leave the latest one
+		for _, scheduledTime := range []int64{1700000060000, 1700000180000, 1700000120000} {
+			if err := store.RecordFire("job-1", scheduledTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if stored := getTestJob(t, store, "job-1"); stored.LastFireTime != 1700000180000 {
+			t.Errorf("got last fire time %d, want 1700000180000", stored.LastFireTime)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	seeds := []struct {
+		jobID        string
+		name         string
+		url          string
+		status       int
+		triggerType  string
+		creationTime int64
+	}{
+		{"job-1", "Billing Report", "http://billing.local/report", orm.JobStatusEnable, "cron", 100},
+		{"job-2", "billing sync", "http://billing.local/sync", orm.JobStatusDisable, "interval", 200},
+		{"job-3", "Cleanup 50%", "http://cleanup.local/run", orm.JobStatusEnable, "interval", 300},
+		{"job-4", "Cleanup 500", "http://cleanup.local/run", orm.JobStatusDone, "once", 400},
+		{"job-5", "a_b", "http://misc.local/x", orm.JobStatusEnable, "cron", 500},
+		{"job-6", "axb", `http://misc.local/a\b`, orm.JobStatusEnable, "cron", 600},
+	}
+
+	tests := []struct {
+		name       string
+		query      JobQuery
+		expected   []string
+		totalCount int
+		// whether the jobs are expected in order
+		ordered bool
+	}{
+		{
+			name:       "all",
+			query:      JobQuery{},
+			expected:   []string{"job-1", "job-2", "job-3", "job-4", "job-5", "job-6"},
+			totalCount: 6,
+		},
+		{
+			name:       "status",
+			query:      JobQuery{Conditions: []JobCondition{{Column: "Status", Operator: OperatorIn, Values: []interface{}{orm.JobStatusDisable, orm.JobStatusDone}}}},
+			expected:   []string{"job-2", "job-4"},
+			totalCount: 2,
+		},
+		{
+			name: "trigger type and creation time",
+			query: JobQuery{Conditions: []JobCondition{
+				{Column: "TriggerType", Operator: OperatorIn, Values: []interface{}{"cron"}},
+				{Column: "CreationTime", Operator: OperatorFrom, Values: []interface{}{int64(100)}},
+				{Column: "CreationTime", Operator: OperatorTo, Values: []interface{}{int64(500)}},
+			}},
+			expected:   []string{"job-1", "job-5"},
+			totalCount: 2,
+		},
+		{
+			name:       "name prefix",
+			query:      JobQuery{Conditions: []JobCondition{{Column: "Name", Operator: OperatorPrefix, Values: []interface{}{"BILLING"}}}},
+			expected:   []string{"job-1", "job-2"},
+			totalCount: 2,
+		},
+		{
+			name:       "name prefix with wildcard",
+			query:      JobQuery{Conditions: []JobCondition{{Column: "Name", Operator: OperatorPrefix, Values: []interface{}{"a_"}}}},
+			expected:   []string{"job-5"},
+			totalCount: 1,
+		},
+		{
+			name:       "search",
+			query:      JobQuery{Search: "REPORT"},
+			expected:   []string{"job-1"},
+			totalCount: 1,
+		},
+		{
+			name:       "search url",
+			query:      JobQuery{Search: "cleanup.local"},
+			expected:   []string{"job-3", "job-4"},
+			totalCount: 2,
+		},
+		{
+			name:       "search percent",
+			query:      JobQuery{Search: "50%"},
+			expected:   []string{"job-3"},
+			totalCount: 1,
+		},
+		{
+			name:       "search underscore",
+			query:      JobQuery{Search: "a_b"},
+			expected:   []string{"job-5"},
+			totalCount: 1,
+		},
+		{
+			name:       "search backslash",
+			query:      JobQuery{Search: `a\b`},
+			expected:   []string{"job-6"},
+			totalCount: 1,
+		},
+		{
+			name:       "order",
+			query:      JobQuery{Orders: []JobOrder{{Column: "Status"}, {Column: "CreationTime", Descending: true}}},
+			expected:   []string{"job-6", "job-5", "job-3", "job-1", "job-2", "job-4"},
+			totalCount: 6,
+			ordered:    true,
+		},
+		{
+			name:       "page",
+			query:      JobQuery{Orders: []JobOrder{{Column: "CreationTime"}}, From: 2, Size: 2},
+			expected:   []string{"job-3", "job-4"},
+			totalCount: 6,
+			ordered:    true,
+		},
+		{
+			name:       "page beyond jobs",
+			query:      JobQuery{Orders: []JobOrder{{Column: "CreationTime"}}, From: 10, Size: 2},
+			expected:   []string{},
+			totalCount: 6,
+			ordered:    true,
+		},
+	}
+
+	forEachStore(t, func(t *testing.T, store Store) {
+		for _, seed := range seeds {
+			scheduleJob := newTestJob(seed.jobID)
+			scheduleJob.Name = seed.name
+			scheduleJob.HttpTargetUrl = seed.url
+			scheduleJob.Status = seed.status
+			scheduleJob.TriggerType = seed.triggerType
+			scheduleJob.CreationTime = seed.creationTime
+			createTestJob(t, store, scheduleJob)
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				scheduleJobs, totalCount, err := store.List(&test.query)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				jobIDs := []string{}
+				for _, scheduleJob := range scheduleJobs {
+					jobIDs = append(jobIDs, scheduleJob.JobID)
+				}
+				if !test.ordered {
+					sort.Strings(jobIDs)
+				}
+
+				if !reflect.DeepEqual(jobIDs, test.expected) || totalCount != test.totalCount {
+					t.Errorf("got %v of %d, want %v of %d", jobIDs, totalCount, test.expected, test.totalCount)
+				}
+			})
+		}
+
+		t.Run("unknown column", func(t *testing.T) {
+			query := JobQuery{Conditions: []JobCondition{{Column: "JsonWebToken", Operator: OperatorIn, Values: []interface{}{"token"}}}}
+			if _, _, err := store.List(&query); err == nil {
+				t.Error("listed jobs by an unknown column")
+			}
+		})
+	})
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		delete   func(store Store) error
+		expected []string
+	}{
+		{name: "one", delete: func(store Store) error { return store.Delete("job-1") }, expected: []string{"job-2"}},
+		{name: "all", delete: func(store Store) error { return store.DeleteAll() }, expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, store Store) {
+				for i, jobID := range []string{"job-1", "job-2"} {
+					createTestJob(t, store, newTestJob(jobID))
+
+					execution := &orm.JobExecution{
+						ExecutionID: fmt.Sprintf("execution-%d", i),
+						JobID:       jobID,
+						Status:      orm.ExecutionStatusSucceeded,
+						StartTime:   1700000000000,
+						TriggeredBy: orm.ExecutionTriggeredBySchedule,
+					}
+					if err := store.CreateExecution(execution); err != nil {
+						t.Fatal(err)
+					}
+					if err := store.CreateExecutionAttempt(&orm.JobExecutionAttempt{ExecutionID: execution.ExecutionID, Attempt: 1, JobID: jobID}); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := store.PutHostLimit(&orm.HostLimit{Host: "localhost", RequestsPerSecond: 1, Burst: 1}); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := test.delete(store); err != nil {
+					t.Fatal(err)
+				}
+
+				// jobs go along with their execution history
+				scheduleJobs, _, err := store.List(&JobQuery{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				jobIDs := []string{}
+				for _, scheduleJob := range scheduleJobs {
+					jobIDs = append(jobIDs, scheduleJob.JobID)
+
+					executions, totalCount, err := store.ListExecutions(scheduleJob.JobID, 0, 10)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if totalCount != 1 || len(executions) != 1 {
+						t.Errorf("got %d executions of %s left, want 1", totalCount, scheduleJob.JobID)
+					}
+				}
+				if !reflect.DeepEqual(jobIDs, test.expected) {
+					t.Errorf("got %v, want %v", jobIDs, test.expected)
+				}
+
+				if _, totalCount, err := store.ListExecutions("job-1", 0, 10); err != nil || totalCount != 0 {
+					t.Errorf("got %d executions of a deleted job, %v", totalCount, err)
+				}
+				if attempts, err := store.ListExecutionAttempts("job-1", "execution-0"); err != nil || len(attempts) != 0 {
+					t.Errorf("got %d attempts of a deleted job, %v", len(attempts), err)
+				}
+
+				// host limits are kept
+				if _, err := store.GetHostLimit("localhost"); err != nil {
+					t.Errorf("got %v getting the host limit", err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+// memoryStore keeps jobs in memory only, which is meant for tests and
+// throwaway deployments
+type memoryStore struct {
+	mtx               sync.Mutex
+	scheduleJobs      map[string]orm.ScheduleJob
+	executions        map[string]orm.JobExecution
+	executionAttempts map[string][]orm.JobExecutionAttempt
+	hostLimits        map[string]orm.HostLimit
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		scheduleJobs:      map[string]orm.ScheduleJob{},
+		executions:        map[string]orm.JobExecution{},
+		executionAttempts: map[string][]orm.JobExecutionAttempt{},
+		hostLimits:        map[string]orm.HostLimit{},
+	}
+}
+
+// jobColumn returns a column of schedule_jobs which jobs are listed by
+func jobColumn(scheduleJob *orm.ScheduleJob, column string) (interface{}, error) {
+	switch column {
+	case "JobID":
+		return scheduleJob.JobID, nil
+	case "Status":
+		return int64(scheduleJob.Status), nil
+	case "Name":
+		return scheduleJob.Name, nil
+	case "TriggerType":
+		return scheduleJob.TriggerType, nil
+	case "HttpMethod":
+		return scheduleJob.HttpMethod, nil
+	case "HttpTargetUrl":
+		return scheduleJob.HttpTargetUrl, nil
+	case "CreationTime":
+		return scheduleJob.CreationTime, nil
+	case "UpdateTime":
+		return scheduleJob.UpdateTime, nil
+	default:
+		return nil, fmt.Errorf("unknown column %s", column)
+	}
+}
+
+// compareValues compares a column with a value of a condition, which is
+// either an integer or a string
+func compareValues(column interface{}, value interface{}) int {
+	if number, ok := column.(int64); ok {
+		other := int64(0)
+		switch value := value.(type) {
+		case int:
+			other = int64(value)
+		case int64:
+			other = value
+		default:
+			return strings.Compare(fmt.Sprint(number), fmt.Sprint(value))
+		}
+
+		switch {
+		case number < other:
+			return -1
+		case number > other:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(column), fmt.Sprint(value))
+}
+
+func matchJob(scheduleJob *orm.ScheduleJob, query *JobQuery) (bool, error) {
+	for _, condition := range query.Conditions {
+		column, err := jobColumn(scheduleJob, condition.Column)
+		if err != nil {
+			return false, err
+		}
+
+		matched := true
+		switch condition.Operator {
+		case OperatorIn:
+			matched = false
+			for _, value := range condition.Values {
+				if compareValues(column, value) == 0 {
+					matched = true
+					break
+				}
+			}
+		case OperatorFrom:
+			for _, value := range condition.Values {
+				matched = matched && compareValues(column, value) >= 0
+			}
+		case OperatorTo:
+			for _, value := range condition.Values {
+				matched = matched && compareValues(column, value) <= 0
+			}
+		case OperatorPrefix:
+			for _, value := range condition.Values {
+				matched = matched && strings.HasPrefix(strings.ToLower(fmt.Sprint(column)), strings.ToLower(fmt.Sprint(value)))
+			}
+		default:
+			return false, fmt.Errorf("unknown operator %s", condition.Operator)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	if query.Search != "" {
+		search := strings.ToLower(query.Search)
+		if !strings.Contains(strings.ToLower(scheduleJob.Name), search) && !strings.Contains(strings.ToLower(scheduleJob.HttpTargetUrl), search) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (store *memoryStore) Create(scheduleJob *orm.ScheduleJob) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	if _, ok := store.scheduleJobs[scheduleJob.JobID]; ok {
+		return fmt.Errorf("duplicate job %s", scheduleJob.JobID)
+	}
+
+	// columns left to their defaults on insert
+	created := *scheduleJob
+	created.PausedTime = 0
+	created.LastFireTime = 0
+	created.RunCount = 0
+	store.scheduleJobs[scheduleJob.JobID] = created
+
+	return nil
+}
+
+func (store *memoryStore) Get(jobID string) (*orm.ScheduleJob, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	scheduleJob, ok := store.scheduleJobs[jobID]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return &scheduleJob, nil
+}
+
+func (store *memoryStore) List(query *JobQuery) ([]orm.ScheduleJob, int, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	scheduleJobs := []orm.ScheduleJob{}
+	for _, scheduleJob := range store.scheduleJobs {
+		matched, err := matchJob(&scheduleJob, query)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if matched {
+			scheduleJobs = append(scheduleJobs, scheduleJob)
+		}
+	}
+
+	for _, order := range query.Orders {
+		if _, err := jobColumn(&orm.ScheduleJob{}, order.Column); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	sort.SliceStable(scheduleJobs, func(i, j int) bool {
+		for _, order := range query.Orders {
+			column1, _ := jobColumn(&scheduleJobs[i], order.Column)
+			column2, _ := jobColumn(&scheduleJobs[j], order.Column)
+
+			result := compareValues(column1, column2)
+			if result == 0 {
+				continue
+			}
+
+			if order.Descending {
+				return result > 0
+			}
+			return result < 0
+		}
+
+		return false
+	})
+
+	totalCount := len(scheduleJobs)
+
+	from := query.From
+	if from > totalCount {
+		from = totalCount
+	}
+	if from < 0 {
+		from = 0
+	}
+	to := totalCount
+	if query.Size != 0 && from+query.Size < to {
+		to = from + query.Size
+	}
+
+	return scheduleJobs[from:to], totalCount, nil
+}
+
+func (store *memoryStore) Update(scheduleJob *orm.ScheduleJob) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[scheduleJob.JobID]
+	if !ok || stored.Version != scheduleJob.Version-1 {
+		return ErrJobVersionConflict
+	}
+
+	// columns kept by the job itself rather than by its settings
+	updated := *scheduleJob
+	updated.JobID = stored.JobID
+	updated.LastFireTime = stored.LastFireTime
+	updated.RunCount = stored.RunCount
+	updated.CreationTime = stored.CreationTime
+	updated.Version = stored.Version + 1
+	store.scheduleJobs[scheduleJob.JobID] = updated
+
+	return nil
+}
+
+func (store *memoryStore) UpdateState(scheduleJob *orm.ScheduleJob) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[scheduleJob.JobID]
+	if !ok || stored.Version != scheduleJob.Version-1 {
+		return ErrJobVersionConflict
+	}
+
+	stored.JobKey = scheduleJob.JobKey
+	stored.Status = scheduleJob.Status
+	stored.PausedTime = scheduleJob.PausedTime
+	stored.UpdateTime = scheduleJob.UpdateTime
+	stored.Version++
+	store.scheduleJobs[scheduleJob.JobID] = stored
+
+	return nil
+}
+
+func (store *memoryStore) Delete(jobID string) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	delete(store.scheduleJobs, jobID)
+	for executionID, execution := range store.executions {
+		if execution.JobID == jobID {
+			delete(store.executions, executionID)
+			delete(store.executionAttempts, executionID)
+		}
+	}
+
+	return nil
+}
+
+func (store *memoryStore) DeleteAll() error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	store.scheduleJobs = map[string]orm.ScheduleJob{}
+	store.executions = map[string]orm.JobExecution{}
+	store.executionAttempts = map[string][]orm.JobExecutionAttempt{}
+
+	return nil
+}
+
+func (store *memoryStore) MarkDone(jobID string) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[jobID]
+	if !ok || stored.Status == orm.JobStatusDone {
+		return nil
+	}
+
+	stored.Status = orm.JobStatusDone
+	stored.Version++
+	store.scheduleJobs[jobID] = stored
+
+	return nil
+}
+
+func (store *memoryStore) UpdateJobKey(scheduleJob *orm.ScheduleJob, jobKey int) (bool, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[scheduleJob.JobID]
+	if !ok || stored.Version != scheduleJob.Version {
+		return false, nil
+	}
+
+	stored.JobKey = jobKey
+	store.scheduleJobs[scheduleJob.JobID] = stored
+
+	return true, nil
+}
+
+func (store *memoryStore) CountRun(jobID string) (bool, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[jobID]
+	if !ok || (stored.MaxRuns != 0 && stored.RunCount >= stored.MaxRuns) {
+		return false, nil
+	}
+
+	stored.RunCount++
+	store.scheduleJobs[jobID] = stored
+
+	return true, nil
+}
+
+func (store *memoryStore) RecordFire(jobID string, scheduledTime int64) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.scheduleJobs[jobID]
+	if !ok || stored.LastFireTime >= scheduledTime {
+		return nil
+	}
+
+	stored.LastFireTime = scheduledTime
+	store.scheduleJobs[jobID] = stored
+
+	return nil
+}
+
+// RotateSecrets has nothing to rotate, since jobs are never kept at rest
+func (store *memoryStore) RotateSecrets() (int, error) {
+	return 0, nil
+}
+
+func (store *memoryStore) CreateExecution(execution *orm.JobExecution) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	if _, ok := store.executions[execution.ExecutionID]; ok {
+		return fmt.Errorf("duplicate execution %s", execution.ExecutionID)
+	}
+
+	store.executions[execution.ExecutionID] = *execution
+
+	return nil
+}
+
+func (store *memoryStore) UpdateExecution(execution *orm.JobExecution) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	stored, ok := store.executions[execution.ExecutionID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = execution.Status
+	stored.EndTime = execution.EndTime
+	stored.HttpStatusCode = execution.HttpStatusCode
+	stored.Latency = execution.Latency
+	stored.Attempts = execution.Attempts
+	stored.ResponseBody = execution.ResponseBody
+	stored.ErrorMessage = execution.ErrorMessage
+	store.executions[execution.ExecutionID] = stored
+
+	return nil
+}
+
+func (store *memoryStore) CreateExecutionAttempt(executionAttempt *orm.JobExecutionAttempt) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	executionID := executionAttempt.ExecutionID
+	for _, stored := range store.executionAttempts[executionID] {
+		if stored.Attempt == executionAttempt.Attempt {
+			return fmt.Errorf("duplicate attempt %d of execution %s", executionAttempt.Attempt, executionID)
+		}
+	}
+
+	store.executionAttempts[executionID] = append(store.executionAttempts[executionID], *executionAttempt)
+
+	return nil
+}
+
+func (store *memoryStore) ListExecutions(jobID string, from int, size int) ([]orm.JobExecution, int, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	jobExecutions := []orm.JobExecution{}
+	for _, execution := range store.executions {
+		if execution.JobID == jobID {
+			jobExecutions = append(jobExecutions, execution)
+		}
+	}
+
+	sort.SliceStable(jobExecutions, func(i, j int) bool {
+		return jobExecutions[i].StartTime > jobExecutions[j].StartTime
+	})
+
+	totalCount := len(jobExecutions)
+
+	if from > totalCount {
+		from = totalCount
+	}
+	if from < 0 {
+		from = 0
+	}
+	to := totalCount
+	if size != 0 && from+size < to {
+		to = from + size
+	}
+
+	return jobExecutions[from:to], totalCount, nil
+}
+
+func (store *memoryStore) GetExecution(jobID string, executionID string) (*orm.JobExecution, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	execution, ok := store.executions[executionID]
+	if !ok || execution.JobID != jobID {
+		return nil, ErrExecutionNotFound
+	}
+
+	return &execution, nil
+}
+
+func (store *memoryStore) ListExecutionAttempts(jobID string, executionID string) ([]orm.JobExecutionAttempt, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	executionAttempts := []orm.JobExecutionAttempt{}
+	for _, executionAttempt := range store.executionAttempts[executionID] {
+		if executionAttempt.JobID == jobID {
+			executionAttempts = append(executionAttempts, executionAttempt)
+		}
+	}
+
+	sort.Slice(executionAttempts, func(i, j int) bool {
+		return executionAttempts[i].Attempt < executionAttempts[j].Attempt
+	})
+
+	return executionAttempts, nil
+}
+
+func (store *memoryStore) ListHostLimits() ([]orm.HostLimit, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	hostLimits := []orm.HostLimit{}
+	for _, hostLimit := range store.hostLimits {
+		hostLimits = append(hostLimits, hostLimit)
+	}
+
+	sort.Slice(hostLimits, func(i, j int) bool {
+		return hostLimits[i].Host < hostLimits[j].Host
+	})
+
+	return hostLimits, nil
+}
+
+func (store *memoryStore) GetHostLimit(host string) (*orm.HostLimit, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	hostLimit, ok := store.hostLimits[host]
+	if !ok {
+		return nil, ErrHostLimitNotFound
+	}
+
+	return &hostLimit, nil
+}
+
+func (store *memoryStore) PutHostLimit(hostLimit *orm.HostLimit) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	store.hostLimits[hostLimit.Host] = *hostLimit
+
+	return nil
+}
+
+func (store *memoryStore) DeleteHostLimit(host string) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	if _, ok := store.hostLimits[host]; !ok {
+		return ErrHostLimitNotFound
+	}
+
+	delete(store.hostLimits, host)
+
+	return nil
+}
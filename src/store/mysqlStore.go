@@ -0,0 +1,18 @@
+package store
+
+import (
+	"database/sql"
+)
+
+// NewMySQLStore keeps jobs in MySQL, where fire claims of jobs are kept as
+// well
+func NewMySQLStore(db *sql.DB) Store {
+	return &sqlStore{
+		db: db,
+		dialect: dialect{
+			like:     "%s LIKE ?",
+			truncate: "TRUNCATE TABLE %s",
+		},
+		historyTables: []string{"job_executions", "job_execution_attempts", "job_fire_claims"},
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"database/sql"
+	"net/url"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // pure Go PostgreSQL driver
+)
+
+// OpenPostgres opens the PostgreSQL database dbName at endpoint (host:port)
+func OpenPostgres(
+	endpoint string,
+	username string,
+	password string,
+	dbName string,
+	sslMode string,
+	maxOpenConns int,
+	maxIdleConns int,
+) (*sql.DB, error) {
+	dataSource := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     endpoint,
+		Path:     dbName,
+		RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+	}
+
+	db, err := sql.Open("pgx", dataSource.String())
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+
+	return db, db.Ping()
+}
+
+// NewPostgresStore keeps jobs in PostgreSQL, which folds unquoted column names
+// to lower case
+func NewPostgresStore(db *sql.DB) Store {
+	return &sqlStore{
+		db: db,
+		dialect: dialect{
+			like:     "%s ILIKE ?",
+			truncate: "TRUNCATE TABLE %s",
+			numbered: true,
+			folded:   true,
+		},
+		historyTables: []string{"job_executions", "job_execution_attempts"},
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cloud01-wu/scheduler/orm"
+)
+
+func newPostgresTestStore() *sqlStore {
+	return NewPostgresStore(nil).(*sqlStore)
+}
+
+func TestPostgresBind(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "no placeholders",
+			query:    "SELECT COUNT(*) FROM schedule_jobs",
+			expected: "SELECT COUNT(*) FROM schedule_jobs",
+		},
+		{
+			name:     "single",
+			query:    "SELECT * FROM schedule_jobs WHERE JobID=?",
+			expected: "SELECT * FROM schedule_jobs WHERE JobID=$1",
+		},
+		{
+			name:     "several",
+			query:    "UPDATE schedule_jobs SET Status=?,Version=Version+1 WHERE JobID=? AND Status<>?;",
+			expected: "UPDATE schedule_jobs SET Status=$1,Version=Version+1 WHERE JobID=$2 AND Status<>$3;",
+		},
+		{
+			name:     "adjacent",
+			query:    "VALUES (?,?,?)",
+			expected: "VALUES ($1,$2,$3)",
+		},
+		{
+			name:     "beyond nine",
+			query:    strings.TrimSuffix(strings.Repeat("?,", 11), ","),
+			expected: "$1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if query := newPostgresTestStore().bind(test.query); query != test.expected {
+				t.Errorf("got %q, want %q", query, test.expected)
+			}
+		})
+	}
+
+	// dialects with ? placeholders leave queries as is
+	query := "SELECT * FROM schedule_jobs WHERE JobID=? AND Version=?"
+	if bound := NewSQLiteStore(nil).(*sqlStore).bind(query); bound != query {
+		t.Errorf("got %q, want %q", bound, query)
+	}
+}
+
+func TestPostgresSelectColumns(t *testing.T) {
+	tests := []struct {
+		name string
+		row  interface{}
+	}{
+		{name: "schedule jobs", row: &orm.ScheduleJob{}},
+		{name: "executions", row: &orm.JobExecution{}},
+		{name: "execution attempts", row: &orm.JobExecutionAttempt{}},
+		{name: "host limits", row: &orm.HostLimit{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// folded column names are aliased back to the names scanned into
+			// the fields of the row, in the order of the fields
+			expected := []string{}
+			rowType := reflect.TypeOf(test.row).Elem()
+			for i := 0; i < rowType.NumField(); i++ {
+				column := rowType.Field(i).Tag.Get("db")
+				expected = append(expected, column+` AS "`+column+`"`)
+			}
+
+			columns := newPostgresTestStore().selectColumns(test.row)
+			if got := strings.Split(columns, ","); !reflect.DeepEqual(got, expected) {
+				t.Errorf("got %v, want %v", got, expected)
+			}
+		})
+	}
+
+	if columns := NewSQLiteStore(nil).(*sqlStore).selectColumns(&orm.ScheduleJob{}); columns != "*" {
+		t.Errorf("got %q of a dialect without folding, want *", columns)
+	}
+}
+
+func TestPostgresCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     JobQuery
+		condition string
+		arguments []interface{}
+	}{
+		{
+			name:      "none",
+			query:     JobQuery{},
+			condition: "",
+			arguments: []interface{}{},
+		},
+		{
+			name: "in and range",
+			query: JobQuery{Conditions: []JobCondition{
+				{Column: "Status", Operator: OperatorIn, Values: []interface{}{1, 2}},
+				{Column: "CreationTime", Operator: OperatorFrom, Values: []interface{}{int64(100)}},
+			}},
+			condition: "WHERE Status IN ($1,$2) AND CreationTime>=$3",
+			arguments: []interface{}{1, 2, int64(100)},
+		},
+		{
+			name:      "prefix",
+			query:     JobQuery{Conditions: []JobCondition{{Column: "Name", Operator: OperatorPrefix, Values: []interface{}{"50%_off"}}}},
+			condition: "WHERE Name ILIKE $1",
+			arguments: []interface{}{`50\%\_off%`},
+		},
+		{
+			name:      "search",
+			query:     JobQuery{Conditions: []JobCondition{{Column: "TriggerType", Operator: OperatorIn, Values: []interface{}{"cron"}}}, Search: `a\b`},
+			condition: "WHERE TriggerType IN ($1) AND (Name ILIKE $2 OR HttpTargetUrl ILIKE $3)",
+			arguments: []interface{}{"cron", `%a\\b%`, `%a\\b%`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newPostgresTestStore()
+
+			condition, arguments, err := store.newCondition(&test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if condition := store.bind(condition); condition != test.condition {
+				t.Errorf("got %q, want %q", condition, test.condition)
+			}
+			if !reflect.DeepEqual(arguments, test.arguments) {
+				t.Errorf("got %v, want %v", arguments, test.arguments)
+			}
+		})
+	}
+}
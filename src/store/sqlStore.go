@@ -0,0 +1,1036 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/blockloop/scan"
+	"github.com/cloud01-wu/scheduler/orm"
+	"github.com/cloud01-wu/scheduler/secret"
+)
+
+// dialect tells the SQL of a database apart
+type dialect struct {
+	// condition matching a column against a LIKE pattern escaped by backslash
+	like string
+	// statement emptying a table
+	truncate string
+	// whether placeholders are numbered as $1, $2 and so on instead of ?
+	numbered bool
+	// whether unquoted column names are folded to lower case, in which case
+	// selected columns are aliased back to the names of orm fields
+	folded bool
+}
+
+// sqlStore keeps jobs in the tables created by the migrations of a database
+type sqlStore struct {
+	db      *sql.DB
+	dialect dialect
+	// tables keyed by JobID besides schedule_jobs, which are emptied along
+	// with jobs
+	historyTables []string
+}
+
+// columns of schedule_jobs keeping secrets, which are sealed at rest
+var secretColumns = []string{"JsonWebToken", "OAuth2ClientSecret", "SigningSecrets"}
+
+// columns of schedule_jobs which jobs are listed by
+var jobQueryColumns = map[string]bool{
+	"JobID":         true,
+	"Status":        true,
+	"Name":          true,
+	"TriggerType":   true,
+	"HttpMethod":    true,
+	"HttpTargetUrl": true,
+	"CreationTime":  true,
+	"UpdateTime":    true,
+}
+
+// prepare prepares a statement written with ? placeholders
+func (store *sqlStore) prepare(query string) (*sql.Stmt, error) {
+	return store.db.Prepare(store.bind(query))
+}
+
+// bind numbers the ? placeholders of a query if the dialect does
+func (store *sqlStore) bind(query string) string {
+	if store.dialect.numbered {
+		tokens := strings.Split(query, "?")
+		builder := strings.Builder{}
+		for i, token := range tokens {
+			if i > 0 {
+				builder.WriteString("$" + strconv.Itoa(i))
+			}
+			builder.WriteString(token)
+		}
+		query = builder.String()
+	}
+
+	return query
+}
+
+// selectColumns lists the columns scanned into row, which is a pointer to an
+// orm struct
+func (store *sqlStore) selectColumns(row interface{}) string {
+	if !store.dialect.folded {
+		return "*"
+	}
+
+	columns := []string{}
+	rowType := reflect.TypeOf(row).Elem()
+	for i := 0; i < rowType.NumField(); i++ {
+		column := rowType.Field(i).Tag.Get("db")
+		columns = append(columns, column+` AS "`+column+`"`)
+	}
+
+	return strings.Join(columns, ",")
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+func (store *sqlStore) Create(scheduleJob *orm.ScheduleJob) error {
+	jsonWebToken, err := secret.Seal(scheduleJob.JsonWebToken)
+	if err != nil {
+		return err
+	}
+
+	oauth2ClientSecret, err := secret.Seal(scheduleJob.OAuth2ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	signingSecrets, err := secret.Seal(scheduleJob.SigningSecrets)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := store.prepare(`
+		INSERT INTO schedule_jobs (JobID,JobKey,Status,Name,TriggerType,Expression,TimeZone,HttpMethod,HttpTargetUrl,HttpRequestBody,HttpHeaders,ContentType,JsonWebToken,SecretRef,AuthMode,OAuth2TokenUrl,OAuth2ClientID,OAuth2ClientSecret,OAuth2Scopes,SigningSecrets,TlsProfile,JitterSeconds,JitterMode,TimeoutSeconds,RetryMaxAttempts,RetryInitialBackoff,RetryMultiplier,RetryMaxBackoff,RetryStatusCodes,MisfireAction,MisfireMaxRuns,StartAt,EndAt,MaxRuns,Version,CreationTime,UpdateTime)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(
+		scheduleJob.JobID,
+		scheduleJob.JobKey,
+		scheduleJob.Status,
+		scheduleJob.Name,
+		scheduleJob.TriggerType,
+		scheduleJob.Expression,
+		scheduleJob.TimeZone,
+		scheduleJob.HttpMethod,
+		scheduleJob.HttpTargetUrl,
+		scheduleJob.HttpRequestBody,
+		scheduleJob.HttpHeaders,
+		scheduleJob.ContentType,
+		jsonWebToken,
+		scheduleJob.SecretRef,
+		scheduleJob.AuthMode,
+		scheduleJob.OAuth2TokenUrl,
+		scheduleJob.OAuth2ClientID,
+		oauth2ClientSecret,
+		scheduleJob.OAuth2Scopes,
+		signingSecrets,
+		scheduleJob.TlsProfile,
+		scheduleJob.JitterSeconds,
+		scheduleJob.JitterMode,
+		scheduleJob.TimeoutSeconds,
+		scheduleJob.RetryMaxAttempts,
+		scheduleJob.RetryInitialBackoff,
+		scheduleJob.RetryMultiplier,
+		scheduleJob.RetryMaxBackoff,
+		scheduleJob.RetryStatusCodes,
+		scheduleJob.MisfireAction,
+		scheduleJob.MisfireMaxRuns,
+		scheduleJob.StartAt,
+		scheduleJob.EndAt,
+		scheduleJob.MaxRuns,
+		scheduleJob.Version,
+		scheduleJob.CreationTime,
+		scheduleJob.UpdateTime,
+	)
+
+	return err
+}
+
+func (store *sqlStore) Get(jobID string) (*orm.ScheduleJob, error) {
+	stmt, err := store.prepare(`
+		SELECT ` + store.selectColumns(&orm.ScheduleJob{}) + `
+		FROM schedule_jobs
+		WHERE JobID=?
+		;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scheduleJob := orm.ScheduleJob{}
+	err = scan.Row(&scheduleJob, rows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = openSecrets(&scheduleJob)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scheduleJob, nil
+}
+
+// newCondition builds the WHERE clause of listing jobs
+func (store *sqlStore) newCondition(query *JobQuery) (string, []interface{}, error) {
+	conditions := []string{}
+	arguments := []interface{}{}
+
+	for _, condition := range query.Conditions {
+		if !jobQueryColumns[condition.Column] {
+			return "", nil, fmt.Errorf("unknown column %s", condition.Column)
+		}
+
+		switch condition.Operator {
+		case OperatorIn:
+			placeholders := strings.TrimSuffix(strings.Repeat("?,", len(condition.Values)), ",")
+			conditions = append(conditions, condition.Column+" IN ("+placeholders+")")
+			arguments = append(arguments, condition.Values...)
+		case OperatorFrom, OperatorTo:
+			for _, value := range condition.Values {
+				conditions = append(conditions, condition.Column+condition.Operator+"?")
+				arguments = append(arguments, value)
+			}
+		case OperatorPrefix:
+			for _, value := range condition.Values {
+				conditions = append(conditions, fmt.Sprintf(store.dialect.like, condition.Column))
+				arguments = append(arguments, escapeLike(fmt.Sprint(value))+"%")
+			}
+		default:
+			return "", nil, fmt.Errorf("unknown operator %s", condition.Operator)
+		}
+	}
+
+	if query.Search != "" {
+		pattern := "%" + escapeLike(query.Search) + "%"
+		conditions = append(conditions, "("+fmt.Sprintf(store.dialect.like, "Name")+" OR "+fmt.Sprintf(store.dialect.like, "HttpTargetUrl")+")")
+		arguments = append(arguments, pattern, pattern)
+	}
+
+	if len(conditions) == 0 {
+		return "", arguments, nil
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), arguments, nil
+}
+
+// newOrder builds the ORDER BY clause of listing jobs
+func (store *sqlStore) newOrder(query *JobQuery) (string, error) {
+	if len(query.Orders) == 0 {
+		return "", nil
+	}
+
+	orders := []string{}
+	for _, order := range query.Orders {
+		if !jobQueryColumns[order.Column] {
+			return "", fmt.Errorf("unknown column %s", order.Column)
+		}
+
+		if order.Descending {
+			orders = append(orders, order.Column+" DESC")
+		} else {
+			orders = append(orders, order.Column+" ASC")
+		}
+	}
+
+	return "ORDER BY " + strings.Join(orders, ","), nil
+}
+
+func (store *sqlStore) List(query *JobQuery) ([]orm.ScheduleJob, int, error) {
+	condition, arguments, err := store.newCondition(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	order, err := store.newOrder(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt1, err := store.prepare(`
+		SELECT COUNT(*) AS TotalCount
+		FROM schedule_jobs
+		` + condition + `
+		;
+	`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt1.Close()
+
+	rows1, err := stmt1.Query(arguments...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows1.Close()
+
+	totalCount := 0
+	err = scan.Row(&totalCount, rows1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	arguments2 := append([]interface{}{}, arguments...)
+	stmt2String := `
+		SELECT ` + store.selectColumns(&orm.ScheduleJob{}) + `
+		FROM schedule_jobs
+		` + condition + `
+		` + order + `
+	`
+	if query.Size != 0 {
+		stmt2String += `LIMIT ? OFFSET ?`
+		arguments2 = append(arguments2, query.Size, query.From)
+	}
+
+	stmt2, err := store.prepare(stmt2String)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt2.Close()
+
+	rows2, err := stmt2.Query(arguments2...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows2.Close()
+
+	scheduleJobs := []orm.ScheduleJob{}
+	err = scan.Rows(&scheduleJobs, rows2)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range scheduleJobs {
+		err = openSecrets(&scheduleJobs[i])
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return scheduleJobs, totalCount, nil
+}
+
+// openSecrets decrypts the secrets of a job read from the database
+func openSecrets(scheduleJob *orm.ScheduleJob) error {
+	jsonWebToken, err := secret.Open(scheduleJob.JsonWebToken)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", scheduleJob.JobID, err)
+	}
+
+	oauth2ClientSecret, err := secret.Open(scheduleJob.OAuth2ClientSecret)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", scheduleJob.JobID, err)
+	}
+
+	signingSecrets, err := secret.Open(scheduleJob.SigningSecrets)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", scheduleJob.JobID, err)
+	}
+
+	scheduleJob.JsonWebToken = jsonWebToken
+	scheduleJob.OAuth2ClientSecret = oauth2ClientSecret
+	scheduleJob.SigningSecrets = signingSecrets
+	return nil
+}
+
+// checkVersion tells whether a conditional update of a job took effect
+func checkVersion(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrJobVersionConflict
+	}
+
+	return nil
+}
+
+func (store *sqlStore) Update(scheduleJob *orm.ScheduleJob) error {
+	jsonWebToken, err := secret.Seal(scheduleJob.JsonWebToken)
+	if err != nil {
+		return err
+	}
+
+	oauth2ClientSecret, err := secret.Seal(scheduleJob.OAuth2ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	signingSecrets, err := secret.Seal(scheduleJob.SigningSecrets)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET
+		JobKey=?,
+		Status=?,
+		Name=?,
+		TriggerType=?,
+		Expression=?,
+		TimeZone=?,
+		HttpMethod=?,
+		HttpTargetUrl=?,
+		HttpRequestBody=?,
+		HttpHeaders=?,
+		ContentType=?,
+		JsonWebToken=?,
+		SecretRef=?,
+		AuthMode=?,
+		OAuth2TokenUrl=?,
+		OAuth2ClientID=?,
+		OAuth2ClientSecret=?,
+		OAuth2Scopes=?,
+		SigningSecrets=?,
+		TlsProfile=?,
+		JitterSeconds=?,
+		JitterMode=?,
+		TimeoutSeconds=?,
+		RetryMaxAttempts=?,
+		RetryInitialBackoff=?,
+		RetryMultiplier=?,
+		RetryMaxBackoff=?,
+		RetryStatusCodes=?,
+		MisfireAction=?,
+		MisfireMaxRuns=?,
+		StartAt=?,
+		EndAt=?,
+		MaxRuns=?,
+		PausedTime=?,
+		UpdateTime=?,
+		Version=Version+1
+		WHERE JobID=? AND Version=?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(
+		scheduleJob.JobKey,
+		scheduleJob.Status,
+		scheduleJob.Name,
+		scheduleJob.TriggerType,
+		scheduleJob.Expression,
+		scheduleJob.TimeZone,
+		scheduleJob.HttpMethod,
+		scheduleJob.HttpTargetUrl,
+		scheduleJob.HttpRequestBody,
+		scheduleJob.HttpHeaders,
+		scheduleJob.ContentType,
+		jsonWebToken,
+		scheduleJob.SecretRef,
+		scheduleJob.AuthMode,
+		scheduleJob.OAuth2TokenUrl,
+		scheduleJob.OAuth2ClientID,
+		oauth2ClientSecret,
+		scheduleJob.OAuth2Scopes,
+		signingSecrets,
+		scheduleJob.TlsProfile,
+		scheduleJob.JitterSeconds,
+		scheduleJob.JitterMode,
+		scheduleJob.TimeoutSeconds,
+		scheduleJob.RetryMaxAttempts,
+		scheduleJob.RetryInitialBackoff,
+		scheduleJob.RetryMultiplier,
+		scheduleJob.RetryMaxBackoff,
+		scheduleJob.RetryStatusCodes,
+		scheduleJob.MisfireAction,
+		scheduleJob.MisfireMaxRuns,
+		scheduleJob.StartAt,
+		scheduleJob.EndAt,
+		scheduleJob.MaxRuns,
+		scheduleJob.PausedTime,
+		scheduleJob.UpdateTime,
+		scheduleJob.JobID,
+		scheduleJob.Version-1,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(res)
+}
+
+func (store *sqlStore) UpdateState(scheduleJob *orm.ScheduleJob) error {
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET
+		JobKey=?,
+		Status=?,
+		PausedTime=?,
+		UpdateTime=?,
+		Version=Version+1
+		WHERE JobID=? AND Version=?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(
+		scheduleJob.JobKey,
+		scheduleJob.Status,
+		scheduleJob.PausedTime,
+		scheduleJob.UpdateTime,
+		scheduleJob.JobID,
+		scheduleJob.Version-1,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkVersion(res)
+}
+
+func (store *sqlStore) Delete(jobID string) error {
+	for _, table := range append([]string{"schedule_jobs"}, store.historyTables...) {
+		stmt, err := store.prepare(`
+			DELETE
+			FROM ` + table + `
+			WHERE JobID=?
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.Exec(jobID)
+		stmt.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *sqlStore) DeleteAll() error {
+	for _, table := range append([]string{"schedule_jobs"}, store.historyTables...) {
+		stmt, err := store.prepare(fmt.Sprintf(store.dialect.truncate, table))
+		if err != nil {
+			return err
+		}
+
+		_, err = stmt.Exec()
+		stmt.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (store *sqlStore) MarkDone(jobID string) error {
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET Status=?,Version=Version+1 WHERE JobID=? AND Status<>?;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(orm.JobStatusDone, jobID, orm.JobStatusDone)
+	return err
+}
+
+func (store *sqlStore) UpdateJobKey(scheduleJob *orm.ScheduleJob, jobKey int) (bool, error) {
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET JobKey=? WHERE JobID=? AND Version=?
+		;
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(jobKey, scheduleJob.JobID, scheduleJob.Version)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected != 0, nil
+}
+
+func (store *sqlStore) CountRun(jobID string) (bool, error) {
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET RunCount=RunCount+1 WHERE JobID=? AND (MaxRuns=0 OR RunCount<MaxRuns)
+		;
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(jobID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (store *sqlStore) RecordFire(jobID string, scheduledTime int64) error {
+	stmt, err := store.prepare(`
+		UPDATE schedule_jobs SET LastFireTime=? WHERE JobID=? AND LastFireTime<?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(scheduledTime, jobID, scheduledTime)
+	return err
+}
+
+func (store *sqlStore) RotateSecrets() (int, error) {
+	rotated := 0
+	for _, column := range secretColumns {
+		count, err := store.rotateSecretColumn(column)
+		rotated += count
+		if err != nil {
+			return rotated, err
+		}
+	}
+
+	return rotated, nil
+}
+
+// rotateSecretColumn seals the stale secrets kept in a column of schedule_jobs
+// again by the primary key
+func (store *sqlStore) rotateSecretColumn(column string) (int, error) {
+	stmt1, err := store.prepare(`
+		SELECT JobID,` + column + ` FROM schedule_jobs WHERE ` + column + `<>''
+		;
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt1.Close()
+
+	rows1, err := stmt1.Query()
+	if err != nil {
+		return 0, err
+	}
+	defer rows1.Close()
+
+	// read every secret ahead of updating them, since SQLite allows a single
+	// connection only
+	storedSecrets := map[string]string{}
+	for rows1.Next() {
+		jobID, storedSecret := "", ""
+		err = rows1.Scan(&jobID, &storedSecret)
+		if err != nil {
+			return 0, err
+		}
+
+		if secret.Stale(storedSecret) {
+			storedSecrets[jobID] = storedSecret
+		}
+	}
+	err = rows1.Err()
+	if err != nil {
+		return 0, err
+	}
+	rows1.Close()
+
+	stmt2, err := store.prepare(`
+		UPDATE schedule_jobs SET ` + column + `=? WHERE JobID=? AND ` + column + `=?
+		;
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt2.Close()
+
+	rotated := 0
+	for jobID, storedSecret := range storedSecrets {
+		plaintext, err := secret.Open(storedSecret)
+		if err != nil {
+			return rotated, fmt.Errorf("job %s: %w", jobID, err)
+		}
+
+		sealed, err := secret.Seal(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+
+		// a job updated in the meantime has had its secret sealed already
+		res, err := stmt2.Exec(sealed, jobID, storedSecret)
+		if err != nil {
+			return rotated, err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return rotated, err
+		}
+		rotated += int(rowsAffected)
+	}
+
+	return rotated, nil
+}
+
+func (store *sqlStore) CreateExecution(execution *orm.JobExecution) error {
+	stmt, err := store.prepare(`
+		INSERT INTO job_executions (ExecutionID,JobID,Status,ScheduledTime,StartTime,EndTime,HttpStatusCode,Latency,Attempts,ResponseBody,ErrorMessage,TriggeredBy)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?)
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(
+		execution.ExecutionID,
+		execution.JobID,
+		execution.Status,
+		execution.ScheduledTime,
+		execution.StartTime,
+		execution.EndTime,
+		execution.HttpStatusCode,
+		execution.Latency,
+		execution.Attempts,
+		execution.ResponseBody,
+		execution.ErrorMessage,
+		execution.TriggeredBy,
+	)
+
+	return err
+}
+
+func (store *sqlStore) UpdateExecution(execution *orm.JobExecution) error {
+	stmt, err := store.prepare(`
+		UPDATE job_executions SET
+		Status=?,
+		EndTime=?,
+		HttpStatusCode=?,
+		Latency=?,
+		Attempts=?,
+		ResponseBody=?,
+		ErrorMessage=?
+		WHERE ExecutionID=?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(
+		execution.Status,
+		execution.EndTime,
+		execution.HttpStatusCode,
+		execution.Latency,
+		execution.Attempts,
+		execution.ResponseBody,
+		execution.ErrorMessage,
+		execution.ExecutionID,
+	)
+
+	return err
+}
+
+func (store *sqlStore) CreateExecutionAttempt(executionAttempt *orm.JobExecutionAttempt) error {
+	stmt, err := store.prepare(`
+		INSERT INTO job_execution_attempts (ExecutionID,Attempt,JobID,StartTime,EndTime,HttpStatusCode,Latency,ErrorMessage)
+		VALUES (?,?,?,?,?,?,?,?)
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(
+		executionAttempt.ExecutionID,
+		executionAttempt.Attempt,
+		executionAttempt.JobID,
+		executionAttempt.StartTime,
+		executionAttempt.EndTime,
+		executionAttempt.HttpStatusCode,
+		executionAttempt.Latency,
+		executionAttempt.ErrorMessage,
+	)
+
+	return err
+}
+
+func (store *sqlStore) ListExecutions(jobID string, from int, size int) ([]orm.JobExecution, int, error) {
+	stmt1, err := store.prepare(`
+		SELECT COUNT(*) AS TotalCount
+		FROM job_executions
+		WHERE JobID=?
+		;
+	`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt1.Close()
+
+	rows1, err := stmt1.Query(jobID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows1.Close()
+
+	totalCount := 0
+	err = scan.Row(&totalCount, rows1)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	arguments2 := []interface{}{jobID}
+	stmt2String := `
+		SELECT ` + store.selectColumns(&orm.JobExecution{}) + `
+		FROM job_executions
+		WHERE JobID=?
+		ORDER BY StartTime DESC
+	`
+	if size != 0 {
+		stmt2String += `LIMIT ? OFFSET ?`
+		arguments2 = append(arguments2, size, from)
+	}
+
+	stmt2, err := store.prepare(stmt2String)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt2.Close()
+
+	rows2, err := stmt2.Query(arguments2...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows2.Close()
+
+	jobExecutions := []orm.JobExecution{}
+	err = scan.Rows(&jobExecutions, rows2)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobExecutions, totalCount, nil
+}
+
+func (store *sqlStore) GetExecution(jobID string, executionID string) (*orm.JobExecution, error) {
+	stmt, err := store.prepare(`
+		SELECT ` + store.selectColumns(&orm.JobExecution{}) + `
+		FROM job_executions
+		WHERE JobID=? AND ExecutionID=?
+		;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(jobID, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	execution := orm.JobExecution{}
+	err = scan.Row(&execution, rows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExecutionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &execution, nil
+}
+
+func (store *sqlStore) ListExecutionAttempts(jobID string, executionID string) ([]orm.JobExecutionAttempt, error) {
+	stmt, err := store.prepare(`
+		SELECT ` + store.selectColumns(&orm.JobExecutionAttempt{}) + `
+		FROM job_execution_attempts
+		WHERE JobID=? AND ExecutionID=?
+		ORDER BY Attempt ASC
+		;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(jobID, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executionAttempts := []orm.JobExecutionAttempt{}
+	err = scan.Rows(&executionAttempts, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return executionAttempts, nil
+}
+
+func (store *sqlStore) ListHostLimits() ([]orm.HostLimit, error) {
+	stmt, err := store.prepare(`
+		SELECT ` + store.selectColumns(&orm.HostLimit{}) + `
+		FROM host_limits
+		ORDER BY Host ASC
+		;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hostLimits := []orm.HostLimit{}
+	err = scan.Rows(&hostLimits, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return hostLimits, nil
+}
+
+func (store *sqlStore) GetHostLimit(host string) (*orm.HostLimit, error) {
+	stmt, err := store.prepare(`
+		SELECT ` + store.selectColumns(&orm.HostLimit{}) + `
+		FROM host_limits
+		WHERE Host=?
+		;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(host)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hostLimit := orm.HostLimit{}
+	err = scan.Row(&hostLimit, rows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHostLimitNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &hostLimit, nil
+}
+
+// PutHostLimit replaces the row of a host within a transaction, which upserts
+// the same way in every dialect
+func (store *sqlStore) PutHostLimit(hostLimit *orm.HostLimit) error {
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(store.bind(`
+		DELETE
+		FROM host_limits
+		WHERE Host=?
+		;
+	`), hostLimit.Host)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(store.bind(`
+		INSERT INTO host_limits (Host,RequestsPerSecond,Burst,MaxInFlight,UpdateTime)
+		VALUES (?,?,?,?,?)
+		;
+	`),
+		hostLimit.Host,
+		hostLimit.RequestsPerSecond,
+		hostLimit.Burst,
+		hostLimit.MaxInFlight,
+		hostLimit.UpdateTime,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (store *sqlStore) DeleteHostLimit(host string) error {
+	stmt, err := store.prepare(`
+		DELETE
+		FROM host_limits
+		WHERE Host=?
+		;
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(host)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrHostLimitNotFound
+	}
+
+	return nil
+}
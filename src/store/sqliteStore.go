@@ -0,0 +1,32 @@
+package store
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite" // pure Go SQLite driver, which builds without cgo
+)
+
+// OpenSQLite opens the SQLite database at path. Writes to SQLite are
+// serialized anyway, so a single connection is shared to avoid lock errors.
+func OpenSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+
+	return db, db.Ping()
+}
+
+// NewSQLiteStore keeps jobs in SQLite for single-node and edge deployments
+func NewSQLiteStore(db *sql.DB) Store {
+	return &sqlStore{
+		db: db,
+		dialect: dialect{
+			like:     `%s LIKE ? ESCAPE '\'`,
+			truncate: "DELETE FROM %s",
+		},
+		historyTables: []string{"job_executions", "job_execution_attempts"},
+	}
+}